@@ -2,6 +2,8 @@ package gamesys
 
 import (
 	"encoding/xml"
+	"image/color"
+	"math"
 
 	"github.com/faiface/pixel"
 )
@@ -12,12 +14,35 @@ type Actor struct {
 	// XMLName is how we reference when loading xml information
 	XMLName xml.Name `xml:"actor"`
 
+	// ID is the name the actor was added to the engine with.
+	ID string `xml:"id,attr"`
+
 	// Position of the actor, needs to be relative to map
 	Position pixel.Vec
 
+	// Parent is the actor this actor is attached to, if any. Attached actors
+	// follow their parent around, and take on its transform and visibility.
+	Parent *Actor
+
+	// Children are the actors attached to this actor.
+	Children []*Actor
+
+	// Offset is where the actor sits relative to its parent, before the
+	// parent transform is applied.
+	Offset pixel.Vec
+
 	// Destinations will be preset by running scripts.
 	Destinations []pixel.Vec
 
+	// Behaviour drives the actor each cycle, when set.
+	Behaviour Behaviour
+
+	// Properties are custom values for game code and scripts.
+	Properties Properties
+
+	// Tags group actors together, such as "enemy" or "shopkeeper".
+	Tags []string
+
 	// Src is the source graphic
 	Src pixel.Picture
 
@@ -30,29 +55,136 @@ type Actor struct {
 	// Speed will set a speed modifier for the actor.
 	Speed float64 `xml:"speed,attr"`
 
+	// Reach is how far in front the actor can interact with things.
+	Reach float64 `xml:"reach,attr"`
+
+	// Facing is the direction the actor faces in degrees, with 0 being right
+	// and 90 being up. It matches the direction given to Scene.MoveActor.
+	Facing int `xml:"facing,attr"`
+
 	// Visible determines if we see the sprite or not.
 	Visible bool
 
+	// Growth is how much the actor is scaled past its normal size, so the
+	// zero value is the normal size and -1 shrinks it away. Use Scale and
+	// SetScale to work with the scale itself.
+	Growth pixel.Vec
+
+	// Rotation is the rotation of the actor in degrees, counter clockwise.
+	Rotation float64
+
+	// Origin is the point the actor scales and rotates around, relative to
+	// the centre of the actor.
+	Origin pixel.Vec
+
+	// FlipX and FlipY will mirror the actor across or up and down.
+	FlipX bool
+	FlipY bool
+
+	// Tint is the color the actor is multiplied by. A zero tint is treated
+	// as white, leaving the actor as it is.
+	Tint color.RGBA
+
+	// Transparency is how see through the actor is, from 0 being opaque to
+	// 1 being invisible, so the zero value leaves the actor showing.
+	Transparency float64
+
+	// Layer is the draw layer of the actor. Higher layers are drawn over
+	// lower ones, and actors on the same layer are sorted by height.
+	Layer int `xml:"layer,attr"`
+
 	// Collision determines if it collides with anything or not
 	Collision bool
+
+	// Radius makes the actor collide as a circle around its centre, rather
+	// than by its clip, when set.
+	Radius float64
+
+	// CollisionLayer are the collision layers the actor sits on, as bits.
+	CollisionLayer uint32
+
+	// CollisionMask are the collision layers the actor reacts to, as bits.
+	CollisionMask uint32
+
+	// Trigger actors report contact with other actors but never block them.
+	Trigger bool
+
+	// contacts are the actors this actor touched on its last move.
+	contacts map[*Actor]bool
+
+	// triggers are the map triggers this actor was inside last cycle.
+	triggers map[*TriggerArea]bool
+
+	// grids are the spatial grids the actor is indexed in.
+	grids []*SpatialGrid
+
+	// Sheet holds the animation frames sliced from Src. Without a sheet the
+	// whole of Src is drawn.
+	Sheet *SpriteSheet
+
+	// Animations are the named animations the actor can play.
+	Animations map[string]*Animation
+
+	// Animation is the name of the currently playing animation.
+	Animation string
+
+	// AutoAnimate will switch between idle and walk animations as the actor
+	// moves, such as "walk_left" or "idle_down".
+	AutoAnimate bool
+
+	// animStart is the game time the current animation started.
+	animStart float64
+
+	// moving indicates the actor moved this cycle.
+	moving bool
 }
 
 // SetClip will create a clipping box based on the current actor position.
+// The box covers the actor once scaled and rotated. Any children are moved
+// to match.
 func (a *Actor) SetClip() {
-	adjust := pixel.V(a.Output.Frame().W()/2, a.Output.Frame().H()/2)
-	a.Clip = a.Output.Frame().Moved(a.Position.Sub(adjust))
+	half := a.Output.Frame().Size().Scaled(0.5)
+	matrix := a.Matrix()
+
+	// Fit our box around all four transformed corners.
+	first := matrix.Project(half.Scaled(-1))
+	a.Clip = pixel.R(first.X, first.Y, first.X, first.Y)
+	for _, corner := range []pixel.Vec{pixel.V(half.X, -half.Y), pixel.V(-half.X, half.Y), half} {
+		c := matrix.Project(corner)
+		a.Clip.Min = pixel.V(math.Min(a.Clip.Min.X, c.X), math.Min(a.Clip.Min.Y, c.Y))
+		a.Clip.Max = pixel.V(math.Max(a.Clip.Max.X, c.X), math.Max(a.Clip.Max.Y, c.Y))
+	}
+	a.reindex()
+
+	// Bring our children along with us.
+	for _, child := range a.Children {
+		child.Position = matrix.Project(child.Offset)
+		child.SetClip()
+	}
 }
 
-// MoveTo will move the actor to an absolute map position.
+// MoveTo will move the actor to an absolute map position, bringing any
+// children along. Attached actors move their offset from the parent.
 func (a *Actor) MoveTo(position pixel.Vec) {
+	if a.Parent != nil {
+		a.Offset = a.Parent.Matrix().Unproject(position)
+	}
 	a.Position = position
 	a.SetClip()
 }
 
 // Move will move the actor according to the provided vector.
 func (a *Actor) Move(distance pixel.Vec) {
-	a.Position = a.Position.Add(distance)
-	a.SetClip()
+	a.MoveTo(a.Position.Add(distance))
+}
+
+// nextDestination will drop the current destination, moving on to the next.
+func (a *Actor) nextDestination() {
+	if len(a.Destinations) > 1 {
+		a.Destinations = a.Destinations[1:]
+	} else {
+		a.Destinations = nil
+	}
 }
 
 // Show will show the actor
@@ -82,12 +214,105 @@ func (a *Actor) Collides(collision bool) {
 // Render will draw out the actor to the output. This often only will need
 // to run on file load, not during running loops.
 func (a *Actor) Render() {
-	a.Output = pixel.NewSprite(a.Src, a.Src.Bounds())
-	a.Clip = a.Output.Frame().Moved(a.Position)
+	frame := a.Src.Bounds()
+	if a.Sheet != nil {
+		frame = a.Sheet.Frame(0)
+	}
+
+	a.Output = pixel.NewSprite(a.Src, frame)
+	a.SetClip()
+}
+
+// UseAnimations will set the sprite sheet and animations for the actor. The
+// actor will start animating automatically.
+func (a *Actor) UseAnimations(sheet *SpriteSheet, animations map[string]*Animation) {
+	a.Sheet = sheet
+	a.Src = sheet.Src
+	a.Animations = animations
+	a.Animation = ""
+	a.AutoAnimate = true
+
+	a.Render()
+	a.SetClip()
+}
+
+// Play will start the named animation from the given game time. If the
+// animation is already playing it will carry on uninterrupted.
+func (a *Actor) Play(name string, time float64) {
+	if name == a.Animation {
+		return
+	}
+
+	a.Animation = name
+	a.animStart = time
+}
+
+// Animate will set the output to the frame of the playing animation for the
+// given game time.
+func (a *Actor) Animate(time float64) {
+	animation, ok := a.Animations[a.Animation]
+	if !ok || a.Sheet == nil {
+		return
+	}
+
+	a.Output.Set(a.Src, a.Sheet.Frame(animation.Frame(time-a.animStart)))
+}
+
+// Face will turn the actor to face the given direction in degrees.
+func (a *Actor) Face(direction int) {
+	a.Facing = ((direction % 360) + 360) % 360
+}
+
+// FacingVec will return a unit vector pointing the way the actor faces.
+func (a *Actor) FacingVec() pixel.Vec {
+	return pixel.Unit(float64(a.Facing) * DegRad)
+}
+
+// FacingName will return the closest of right, up, left or down to the way
+// the actor is facing.
+func (a *Actor) FacingName() string {
+	return []string{"right", "up", "left", "down"}[((a.Facing+45)%360)/90]
+}
+
+// IsFacing will indicate if the target point is within 45 degrees either
+// side of the way the actor faces.
+func (a *Actor) IsFacing(target pixel.Vec) bool {
+	toTarget := a.Position.To(target)
+	if toTarget.Len() == 0 {
+		return true
+	}
+	return toTarget.Unit().Dot(a.FacingVec()) >= math.Cos(45*DegRad)
+}
+
+// InFront will return the area reaching out in front of the actor, the
+// size of the actor clip. This is what the actor can interact with.
+func (a *Actor) InFront(reach float64) pixel.Rect {
+	return a.Clip.Moved(a.FacingVec().Scaled(reach))
+}
+
+// moved will note that the actor moved this cycle, facing the way it went.
+func (a *Actor) moved(movement pixel.Vec) {
+	if movement.Len() > 0 {
+		a.Face(int(math.Round(movement.Angle() * RadDeg)))
+		a.moving = true
+	}
+}
+
+// directionalAnimation will find the best animation for the state and the
+// direction the actor is facing, such as "walk_left". We fall back to the
+// plain state, then whatever is already playing.
+func (a *Actor) directionalAnimation(state string) string {
+	for _, name := range []string{state + "_" + a.FacingName(), state} {
+		if _, ok := a.Animations[name]; ok {
+			return name
+		}
+	}
+
+	return a.Animation
 }
 
 // Draw will draw the respective actor to the provided destination.
 func (a *Actor) Draw(v *View) {
-	drawMatrix := pixel.IM.Moved(a.Position.Sub(v.Camera.Min))
-	a.Output.Draw(v.Rendered, drawMatrix)
+	drawMatrix := a.Matrix().Moved(v.Camera.Min.Scaled(-1))
+	a.Output.DrawColorMask(v.Rendered, drawMatrix, a.ColorMask())
 }
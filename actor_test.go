@@ -0,0 +1,27 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorFacing(t *testing.T) {
+	actor := &Actor{Position: pixel.V(100, 100), Clip: pixel.R(90, 90, 110, 110)}
+
+	// Directions are kept within a full turn.
+	actor.Face(-90)
+	assert.Equal(t, 270, actor.Facing, "-90 should be 270")
+	assert.Equal(t, "down", actor.FacingName(), "270 should be facing down")
+
+	// Moving will turn the actor.
+	actor.moved(pixel.V(-5, 0))
+	assert.Equal(t, 180, actor.Facing, "Moving left should face 180")
+	assert.Equal(t, "left", actor.FacingName(), "180 should be facing left")
+
+	// What's in front of us.
+	assert.True(t, actor.IsFacing(pixel.V(50, 110)), "We should be facing a point to the left")
+	assert.False(t, actor.IsFacing(pixel.V(150, 100)), "We should not face a point behind us")
+	assert.Equal(t, pixel.R(58, 90, 78, 110), actor.InFront(32), "In front should be one reach over")
+}
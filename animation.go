@@ -0,0 +1,330 @@
+package gamesys
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// AnimationMode is how an animation plays through its frames.
+type AnimationMode int
+
+const (
+	// AnimationLoop starts over once the last frame is done.
+	AnimationLoop AnimationMode = iota
+
+	// AnimationOnce stops on the last frame.
+	AnimationOnce
+
+	// AnimationPingPong plays forward, then backward, then forward again.
+	AnimationPingPong
+)
+
+// SpriteSheet is a picture sliced up into frames.
+type SpriteSheet struct {
+	// Src is the source graphic holding all of the frames.
+	Src pixel.Picture
+
+	// Frames are the areas of the source for each frame, numbered left to
+	// right and top to bottom.
+	Frames []pixel.Rect
+}
+
+// NewGridSheet will slice a picture into a grid of equally sized frames.
+// Frames without a size leave the sheet empty.
+func NewGridSheet(src pixel.Picture, width float64, height float64) *SpriteSheet {
+	sheet := &SpriteSheet{Src: src, Frames: make([]pixel.Rect, 0)}
+	bounds := src.Bounds()
+	if width <= 0 || height <= 0 {
+		return sheet
+	}
+
+	// Pixel works from the bottom up, so we work down from the top row.
+	for y := bounds.Max.Y - height; y >= bounds.Min.Y; y -= height {
+		for x := bounds.Min.X; x+width <= bounds.Max.X; x += width {
+			sheet.Frames = append(sheet.Frames, pixel.R(x, y, x+width, y+height))
+		}
+	}
+
+	return sheet
+}
+
+// NewTilesetSheet will slice a picture using the tile layout of a Tiled
+// tileset, so frames match the tile ids.
+func NewTilesetSheet(src pixel.Picture, tileset *tiled.Tileset) *SpriteSheet {
+	sheet := &SpriteSheet{Src: src, Frames: make([]pixel.Rect, tileset.TileCount)}
+	height := src.Bounds().H()
+
+	for i := range sheet.Frames {
+		// Tiled works from the top down, so we have to reverse y.
+		r := tileset.GetTileRect(uint32(i))
+		sheet.Frames[i] = pixel.R(float64(r.Min.X), height-float64(r.Max.Y), float64(r.Max.X), height-float64(r.Min.Y))
+	}
+
+	return sheet
+}
+
+// LoadTileset will load a Tiled tileset file, for slicing sprite sheets
+// with NewTilesetSheet.
+func LoadTileset(file string) (*tiled.Tileset, error) {
+	source, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	tileset := &tiled.Tileset{}
+	err = xml.Unmarshal(source, tileset)
+	if err != nil {
+		return nil, err
+	}
+	return tileset, nil
+}
+
+// Frame will return the area of the requested frame.
+func (s *SpriteSheet) Frame(index int) pixel.Rect {
+	if index < 0 || index >= len(s.Frames) {
+		return s.Src.Bounds()
+	}
+	return s.Frames[index]
+}
+
+// Animation is a named sequence of sprite sheet frames.
+type Animation struct {
+	// Name is how we select the animation.
+	Name string
+
+	// Frames are the sprite sheet frames to show, in order.
+	Frames []int
+
+	// Durations is how long each frame is shown, in seconds. When there are
+	// fewer durations than frames, the last duration is used for the rest.
+	Durations []float64
+
+	// Mode is how the animation plays through its frames.
+	Mode AnimationMode
+}
+
+// Duration will return how long the given step of the animation lasts.
+func (a *Animation) Duration(step int) float64 {
+	if len(a.Durations) == 0 {
+		return 0
+	}
+	if step >= len(a.Durations) {
+		return a.Durations[len(a.Durations)-1]
+	}
+	return a.Durations[step]
+}
+
+// Frame will return the sprite sheet frame to show after the given amount
+// of time has passed since the animation started.
+func (a *Animation) Frame(elapsed float64) int {
+	if len(a.Frames) == 0 {
+		return 0
+	}
+
+	// Ping pong plays back down the frames, without repeating the ends.
+	steps := make([]int, len(a.Frames))
+	for i := range a.Frames {
+		steps[i] = i
+	}
+	if a.Mode == AnimationPingPong {
+		for i := len(a.Frames) - 2; i > 0; i-- {
+			steps = append(steps, i)
+		}
+	}
+
+	// Find our total running time.
+	total := 0.0
+	for _, s := range steps {
+		total += a.Duration(s)
+	}
+	if total <= 0 {
+		return a.Frames[0]
+	}
+
+	if elapsed >= total {
+		if a.Mode == AnimationOnce {
+			return a.Frames[len(a.Frames)-1]
+		}
+		elapsed = elapsed - float64(int(elapsed/total))*total
+	}
+
+	// Walk through until we run out of time.
+	for _, s := range steps {
+		elapsed -= a.Duration(s)
+		if elapsed < 0 {
+			return a.Frames[s]
+		}
+	}
+
+	return a.Frames[steps[len(steps)-1]]
+}
+
+// AnimationSet is the collection of animations for an actor, along with how
+// to slice up its sprite sheet.
+type AnimationSet struct {
+	// XMLName is how we reference when loading xml information.
+	XMLName xml.Name `xml:"animations"`
+
+	// Image is the sprite sheet file, within the characters directory.
+	Image string `xml:"image,attr"`
+
+	// Tileset is a Tiled tileset file, within the characters directory, to
+	// slice the sprite sheet the way the tileset does. Frames are then tile
+	// ids, and the sheet is the tileset image unless Image is set.
+	Tileset string `xml:"tileset,attr"`
+
+	// FrameWidth is the width of each frame on the grid.
+	FrameWidth float64 `xml:"framewidth,attr"`
+
+	// FrameHeight is the height of each frame on the grid.
+	FrameHeight float64 `xml:"frameheight,attr"`
+
+	// Definitions are the animations as written in the file.
+	Definitions []AnimationDefinition `xml:"animation"`
+
+	// Animations are the processed animations, by name.
+	Animations map[string]*Animation `xml:"-"`
+}
+
+// AnimationDefinition is an animation as it is written in xml. Frames and
+// durations are comma separated lists.
+type AnimationDefinition struct {
+	XMLName  xml.Name `xml:"animation"`
+	Name     string   `xml:"name,attr"`
+	Frames   string   `xml:"frames,attr"`
+	Duration string   `xml:"duration,attr"`
+	Mode     string   `xml:"mode,attr"`
+}
+
+// Animation will build the animation from its xml definition.
+func (d AnimationDefinition) Animation() (*Animation, error) {
+	newAnimation := &Animation{Name: d.Name, Frames: make([]int, 0), Durations: make([]float64, 0)}
+
+	for _, f := range strings.Split(d.Frames, ",") {
+		frame, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, errors.New("animation: invalid frame in " + d.Name)
+		}
+		newAnimation.Frames = append(newAnimation.Frames, frame)
+	}
+
+	for _, t := range strings.Split(d.Duration, ",") {
+		duration, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return nil, errors.New("animation: invalid duration in " + d.Name)
+		}
+		newAnimation.Durations = append(newAnimation.Durations, duration)
+	}
+
+	switch d.Mode {
+	case "once":
+		newAnimation.Mode = AnimationOnce
+	case "pingpong":
+		newAnimation.Mode = AnimationPingPong
+	default:
+		newAnimation.Mode = AnimationLoop
+	}
+
+	return newAnimation, nil
+}
+
+// LoadAnimations will load an animation set from the provided XML file.
+func LoadAnimations(file string) (*AnimationSet, error) {
+	newSet := &AnimationSet{Animations: make(map[string]*Animation)}
+
+	xmlFile, err := os.Open(file)
+	if err != nil {
+		return newSet, err
+	}
+	defer xmlFile.Close()
+
+	byteValue, _ := ioutil.ReadAll(xmlFile)
+	err = xml.Unmarshal(byteValue, newSet)
+	if err != nil {
+		return newSet, err
+	}
+	if newSet.Tileset == "" && (newSet.FrameWidth <= 0 || newSet.FrameHeight <= 0) {
+		return newSet, errors.New("animation: framewidth and frameheight must be above 0, or a tileset given, in " + file)
+	}
+
+	// Process our definitions into usable animations.
+	for _, d := range newSet.Definitions {
+		newAnimation, err := d.Animation()
+		if err != nil {
+			return newSet, err
+		}
+		newSet.Animations[newAnimation.Name] = newAnimation
+	}
+
+	return newSet, nil
+}
+
+// LoadActorAnimations will load an animation file from the characters
+// directory and apply it to the actor, switching to its sprite sheet.
+func (e *Engine) LoadActorAnimations(actor *Actor, file string) error {
+	set, err := LoadAnimations(e.Config.System.Directory.Characters + "/" + file)
+	if err != nil {
+		return err
+	}
+
+	// Tilesets bring their own sprite sheet, unless the set names another.
+	var tileset *tiled.Tileset
+	if set.Tileset != "" {
+		tileset, err = LoadTileset(e.Config.System.Directory.Characters + "/" + set.Tileset)
+		if err != nil {
+			return err
+		}
+		if tileset.Image == nil {
+			return errors.New("animation: tileset " + set.Tileset + " has no image to slice")
+		}
+		if set.Image == "" {
+			set.Image = filepath.Join(filepath.Dir(set.Tileset), tileset.Image.Source)
+		}
+	}
+
+	// The set may use its own sprite sheet, otherwise slice the current one.
+	if set.Image != "" {
+		actor.Src, err = LoadImage(e.Config.System.Directory.Characters + "/" + set.Image)
+		if err != nil {
+			return err
+		}
+	}
+
+	sheet := NewGridSheet(actor.Src, set.FrameWidth, set.FrameHeight)
+	if tileset != nil {
+		sheet = NewTilesetSheet(actor.Src, tileset)
+	}
+	actor.UseAnimations(sheet, set.Animations)
+
+	return nil
+}
+
+// ProcessActorAnimations will advance the animation of every actor on the
+// scene. Actors set to animate automatically will switch between idle and
+// walk animations, depending on how they moved this cycle.
+func (s *Scene) ProcessActorAnimations() {
+	for _, a := range s.Actors {
+		if a.Sheet == nil {
+			continue
+		}
+
+		if a.AutoAnimate {
+			state := "idle"
+			if a.moving {
+				state = "walk"
+			}
+			a.Play(a.directionalAnimation(state), s.Engine.Time)
+		}
+
+		a.Animate(s.Engine.Time)
+		a.moving = false
+	}
+}
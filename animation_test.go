@@ -0,0 +1,72 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGridSheet(t *testing.T) {
+	src, err := LoadImage("test_assets/characters/lizard.png")
+	assert.NoError(t, err)
+
+	// Our 32x32 image gives us 4 frames, starting from the top left.
+	sheet := NewGridSheet(src, 16, 16)
+	assert.Equal(t, 4, len(sheet.Frames), "We should have 4 frames.")
+	assert.Equal(t, pixel.R(0, 16, 16, 32), sheet.Frames[0], "First frame should be top left.")
+	assert.Equal(t, pixel.R(16, 0, 32, 16), sheet.Frames[3], "Last frame should be bottom right.")
+
+	// Frames without a size give us nothing, rather than looping forever.
+	assert.Empty(t, NewGridSheet(src, 0, 16).Frames, "We should have no frames.")
+	assert.Empty(t, NewGridSheet(src, 16, -1).Frames, "We should have no frames.")
+}
+
+func TestLoadTilesetAnimations(t *testing.T) {
+	set, err := LoadAnimations("test_assets/characters/lizardtiles.xml")
+	assert.NoError(t, err, "A tileset stands in for a frame size.")
+	assert.Len(t, set.Animations, 2)
+
+	// The tileset slices its own image, frames being tile ids.
+	hero := newTestActor("hero", pixel.ZV)
+	assert.NoError(t, testEngine.LoadActorAnimations(hero, "lizardtiles.xml"))
+	if assert.NotNil(t, hero.Sheet) {
+		assert.Len(t, hero.Sheet.Frames, 4)
+		assert.Equal(t, pixel.R(0, 16, 16, 32), hero.Sheet.Frames[0], "Tile 0 is the top left.")
+		assert.Equal(t, pixel.R(16, 0, 32, 16), hero.Sheet.Frames[3])
+		assert.Equal(t, pixel.R(0, 0, 32, 32), hero.Src.Bounds(), "The tileset image should be used.")
+	}
+}
+
+func TestLoadAnimations(t *testing.T) {
+	_, err := LoadAnimations("blahblah")
+	assert.Error(t, err, "We should throw an error on bad file load")
+
+	_, err = LoadAnimations("test_assets/characters/noframesize.xml")
+	assert.Error(t, err, "We should throw an error without a frame size")
+
+	set, err := LoadAnimations("test_assets/characters/lizard.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(set.Animations), "We should have 5 animations.")
+
+	// Looping wraps back around.
+	loop := set.Animations["walk_down"]
+	assert.Equal(t, 0, loop.Frame(0.1))
+	assert.Equal(t, 1, loop.Frame(0.3))
+	assert.Equal(t, 0, loop.Frame(0.5))
+
+	// Per frame durations.
+	uneven := set.Animations["walk_up"]
+	assert.Equal(t, 3, uneven.Frame(0.5))
+	assert.Equal(t, 2, uneven.Frame(0.7))
+
+	// Ping pong comes back down without repeating the ends.
+	pingpong := set.Animations["walk_left"]
+	assert.Equal(t, 2, pingpong.Frame(0.25))
+	assert.Equal(t, 1, pingpong.Frame(0.35))
+	assert.Equal(t, 0, pingpong.Frame(0.45))
+
+	// Once stays on the last frame.
+	once := set.Animations["walk_right"]
+	assert.Equal(t, 3, once.Frame(5))
+}
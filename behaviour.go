@@ -0,0 +1,184 @@
+package gamesys
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// Behaviour drives an actor every cycle, such as wandering about or
+// following another actor around. Behaviours move actors by setting their
+// destinations.
+type Behaviour interface {
+	Update(s *Scene, a *Actor)
+}
+
+// Wander will stroll to random spots within a region, pausing in between.
+type Wander struct {
+	// Region is the area the actor wanders within.
+	Region pixel.Rect
+
+	// Pause is the longest wait between strolls, in seconds.
+	Pause float64
+
+	// wait is the time left before the next stroll.
+	wait float64
+}
+
+// Update will pick somewhere new to go once we have arrived and rested.
+func (w *Wander) Update(s *Scene, a *Actor) {
+	if a.Destinations != nil {
+		return
+	}
+
+	w.wait -= s.Engine.Dt
+	if w.wait > 0 {
+		return
+	}
+
+	target := pixel.V(
+		w.Region.Min.X+rand.Float64()*w.Region.W(),
+		w.Region.Min.Y+rand.Float64()*w.Region.H(),
+	)
+	a.Destinations = []pixel.Vec{target}
+	w.wait = rand.Float64() * w.Pause
+}
+
+// Patrol will walk along a set of points, either looping back to the start
+// or turning around at the end.
+type Patrol struct {
+	// Points are the spots to walk between, in order.
+	Points []pixel.Vec
+
+	// PingPong will turn around at the end instead of looping.
+	PingPong bool
+
+	// next is the point we are heading to, and step is which way we go.
+	next int
+	step int
+}
+
+// Update will head to the next point once we reach the last one.
+func (p *Patrol) Update(s *Scene, a *Actor) {
+	if a.Destinations != nil || len(p.Points) == 0 {
+		return
+	}
+
+	a.Destinations = []pixel.Vec{p.Points[p.next]}
+
+	if p.step == 0 {
+		p.step = 1
+	}
+
+	// Work out where we go after this.
+	next := p.next + p.step
+	if next < 0 || next >= len(p.Points) {
+		if p.PingPong && len(p.Points) > 1 {
+			p.step = -p.step
+			next = p.next + p.step
+		} else {
+			next = 0
+		}
+	}
+	p.next = next
+}
+
+// Follow will keep within a distance of another actor, like a party member.
+type Follow struct {
+	// Target is the id of the actor to follow.
+	Target string
+
+	// Distance is how close we keep to the target.
+	Distance float64
+}
+
+// Update will close the gap when the target gets too far away.
+func (f *Follow) Update(s *Scene, a *Actor) {
+	target, ok := s.Actors[f.Target]
+	if !ok {
+		return
+	}
+
+	gap := target.Position.To(a.Position)
+	if gap.Len() <= f.Distance {
+		a.Destinations = nil
+		return
+	}
+
+	a.Destinations = []pixel.Vec{target.Position.Add(gap.Unit().Scaled(f.Distance))}
+}
+
+// Flee will run from another actor whenever it comes within a distance.
+type Flee struct {
+	// Target is the id of the actor to run from.
+	Target string
+
+	// Distance is how close the target can get before we run.
+	Distance float64
+}
+
+// Update will run directly away from the target when it gets too close.
+func (f *Flee) Update(s *Scene, a *Actor) {
+	target, ok := s.Actors[f.Target]
+	if !ok {
+		return
+	}
+
+	gap := target.Position.To(a.Position)
+	if gap.Len() >= f.Distance {
+		a.Destinations = nil
+		return
+	}
+
+	// Straight away, or any way at all if we are right on top of them.
+	away := pixel.Unit(rand.Float64() * 360 * DegRad)
+	if gap.Len() > 0 {
+		away = gap.Unit()
+	}
+	a.Destinations = []pixel.Vec{target.Position.Add(away.Scaled(f.Distance))}
+}
+
+// NewBehaviour will build one of our built in behaviours for the actor,
+// being wander, patrol, follow or flee. Options are read as follows, which
+// match the Tiled object properties.
+//
+//	wander: wanderRadius, pause
+//	patrol: patrol (name of a polyline or polygon map object), pingpong
+//	follow and flee: target (actor id), distance
+//
+// Using none will return a nil behaviour.
+func (s *Scene) NewBehaviour(actor *Actor, kind string, options map[string]string) (Behaviour, error) {
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "wander":
+		radius := StrFloat(options["wanderRadius"])
+		region := pixel.R(actor.Position.X-radius, actor.Position.Y-radius, actor.Position.X+radius, actor.Position.Y+radius)
+		return &Wander{Region: region, Pause: StrFloat(options["pause"])}, nil
+	case "patrol":
+		if s.MapData == nil {
+			return nil, errors.New("newbehaviour: patrol needs mapdata")
+		}
+		obj := s.MapData.FindObject(options["patrol"])
+		if obj == nil {
+			return nil, errors.New("newbehaviour: patrol object not found")
+		}
+		return &Patrol{Points: s.MapData.ObjectPoints(obj), PingPong: StrBool(options["pingpong"])}, nil
+	case "follow":
+		return &Follow{Target: options["target"], Distance: StrFloat(options["distance"])}, nil
+	case "flee":
+		return &Flee{Target: options["target"], Distance: StrFloat(options["distance"])}, nil
+	}
+
+	return nil, errors.New("newbehaviour: unknown behaviour " + kind)
+}
+
+// ProcessActorBehaviours will run the behaviour of every actor on the scene.
+func (s *Scene) ProcessActorBehaviours() {
+	for _, a := range s.Actors {
+		if a.Behaviour != nil {
+			a.Behaviour.Update(s, a)
+		}
+	}
+}
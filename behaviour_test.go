@@ -0,0 +1,72 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatrol(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	guard := &Actor{}
+	points := []pixel.Vec{pixel.V(0, 0), pixel.V(10, 0), pixel.V(20, 0)}
+
+	// Collect where each leg of the patrol takes us.
+	legs := func(p *Patrol, count int) []pixel.Vec {
+		visited := make([]pixel.Vec, 0)
+		for i := 0; i < count; i++ {
+			guard.Destinations = nil
+			p.Update(scene, guard)
+			visited = append(visited, guard.Destinations[0])
+		}
+		return visited
+	}
+
+	looping := legs(&Patrol{Points: points}, 4)
+	assert.Equal(t, []pixel.Vec{points[0], points[1], points[2], points[0]}, looping, "We should loop back to the start.")
+
+	pingpong := legs(&Patrol{Points: points, PingPong: true}, 5)
+	assert.Equal(t, []pixel.Vec{points[0], points[1], points[2], points[1], points[0]}, pingpong, "We should turn around at the end.")
+}
+
+func TestFollowAndFlee(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	hero := &Actor{Position: pixel.V(0, 0)}
+	ally := &Actor{Position: pixel.V(100, 0)}
+	scene.Actors["hero"] = hero
+
+	// Too far away, so we close in to our distance.
+	follow := &Follow{Target: "hero", Distance: 20}
+	follow.Update(scene, ally)
+	assert.Equal(t, []pixel.Vec{pixel.V(20, 0)}, ally.Destinations, "We should catch up to within 20.")
+
+	// Close enough already.
+	ally.Position = pixel.V(10, 0)
+	follow.Update(scene, ally)
+	assert.Nil(t, ally.Destinations, "We should stay put when close.")
+
+	// Running away heads straight out past our distance.
+	flee := &Flee{Target: "hero", Distance: 50}
+	flee.Update(scene, ally)
+	assert.Equal(t, []pixel.Vec{pixel.V(50, 0)}, ally.Destinations, "We should run directly away.")
+}
+
+func TestNewBehaviour(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	actor := &Actor{Position: pixel.V(100, 100)}
+
+	wander, err := scene.NewBehaviour(actor, "wander", map[string]string{"wanderRadius": "50"})
+	assert.NoError(t, err)
+	assert.Equal(t, pixel.R(50, 50, 150, 150), wander.(*Wander).Region, "We should wander around home.")
+
+	none, err := scene.NewBehaviour(actor, "none", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, none, "None should give no behaviour.")
+
+	_, err = scene.NewBehaviour(actor, "dance", nil)
+	assert.Error(t, err, "Unknown behaviours should error.")
+
+	_, err = scene.NewBehaviour(actor, "patrol", map[string]string{"patrol": "route"})
+	assert.Error(t, err, "Patrols need a map.")
+}
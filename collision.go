@@ -0,0 +1,228 @@
+package gamesys
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	// CollisionAll is a collision mask covering every layer.
+	CollisionAll uint32 = 0xFFFFFFFF
+)
+
+// CollidesWith will indicate if the actor reacts to the other actor. Both
+// need collision turned on, and the actor mask has to include a layer the
+// other actor is on. Actors attached together never collide.
+func (a *Actor) CollidesWith(other *Actor) bool {
+	if a.Root() == other.Root() || !a.Collision || !other.Collision {
+		return false
+	}
+	return a.CollisionMask&other.CollisionLayer != 0
+}
+
+// Blocks will indicate if the other actor stops this actor moving. Trigger
+// actors never block, they only report contact.
+func (a *Actor) Blocks(other *Actor) bool {
+	return a.CollidesWith(other) && !a.Trigger && !other.Trigger
+}
+
+// ActorCollisionFree will indicate if the actor can move into the clip
+// without being blocked by other actors on the scene. Contact events are
+// emitted for both actors when they first touch. Actors that block only
+// need to share an edge to touch, as that is as close as they get.
+func (s *Scene) ActorCollisionFree(actor *Actor, clip pixel.Rect) bool {
+	free := true
+	contacts := make(map[*Actor]bool)
+
+	for _, other := range s.nearbyActors(clip, actor.CollidesWith) {
+		blocks := actor.Blocks(other)
+		if other.Clip.Intersects(clip) {
+			contacts[other] = true
+			if blocks {
+				free = false
+			}
+		} else if blocks && boundsTouch(other.Clip, clip) {
+			contacts[other] = true
+		}
+	}
+
+	// Only report new contacts, so held movement doesn't spam events.
+	for other := range contacts {
+		if !actor.contacts[other] {
+			s.emitContact(actor, other)
+		}
+	}
+	actor.contacts = contacts
+
+	return free
+}
+
+// emitContact will emit the contact events for both actors. Solid actors
+// emit "collision", while anything involving a trigger emits "trigger".
+func (s *Scene) emitContact(actor *Actor, other *Actor) {
+	name := "collision"
+	if actor.Trigger || other.Trigger {
+		name = "trigger"
+	}
+
+	s.Engine.Emit(&Event{Name: name, Actor: actor, Other: other})
+	s.Engine.Emit(&Event{Name: name, Actor: other, Other: actor})
+}
+
+// ResolveMovement will work out how far the actor can actually travel along
+// the movement. Each axis is swept on its own, so the actor slides along
+// walls rather than sticking to them, and long moves can't tunnel through
+// thin walls. The actor clip is shrunk by the scene skin width first.
+// Rects are swept exactly, while other shapes and circular actors are
+// stepped along a pixel at a time. Contact events are emitted for where the
+// actor ends up, not where it wanted to go.
+func (s *Scene) ResolveMovement(actor *Actor, movement pixel.Vec) pixel.Vec {
+	if !actor.Collision {
+		return movement
+	}
+
+	clip := s.Skinned(actor.Clip)
+	reach := clip.Union(clip.Moved(movement))
+	if actor.Radius > 0 {
+		reach = pixel.R(reach.Min.X-actor.Radius, reach.Min.Y-actor.Radius, reach.Max.X+actor.Radius, reach.Max.Y+actor.Radius)
+	}
+	rects, shapes := s.blockers(actor, reach)
+
+	// Circles can't be swept like rects, so step against everything.
+	if actor.Radius > 0 {
+		for _, r := range rects {
+			shapes = append(shapes, &RectShape{Rect: r})
+		}
+		rects = nil
+	}
+
+	// Sweep across, then sweep up or down from wherever we ended up.
+	dx := sweep(clip, movement.X, true, rects)
+	dx = s.step(actor, clip, pixel.V(dx, 0), shapes).X
+	clip = clip.Moved(pixel.V(dx, 0))
+	dy := sweep(clip, movement.Y, false, rects)
+	dy = s.step(actor, clip, pixel.V(0, dy), shapes).Y
+	resolved := pixel.V(dx, dy)
+
+	// Let any actors we actually reach know about it.
+	s.ActorCollisionFree(actor, actor.Clip.Moved(resolved))
+
+	return resolved
+}
+
+// Skinned will shrink the clip by the scene skin width on every side, so
+// actors can brush past walls without catching on them.
+func (s *Scene) Skinned(clip pixel.Rect) pixel.Rect {
+	skin := math.Min(s.Skin, math.Min(clip.W(), clip.H())/2)
+	return pixel.R(clip.Min.X+skin, clip.Min.Y+skin, clip.Max.X-skin, clip.Max.Y-skin)
+}
+
+// blockers will gather everything within reach that stops the actor moving,
+// being the map collision shapes and any solid actors. Rects are returned on
+// their own as they can be swept exactly.
+func (s *Scene) blockers(actor *Actor, reach pixel.Rect) ([]pixel.Rect, []Shape) {
+	rects := make([]pixel.Rect, 0)
+	shapes := make([]Shape, 0)
+
+	if s.MapData != nil {
+		for _, c := range s.MapData.CollisionNear(reach) {
+			if r, ok := c.(*RectShape); ok {
+				rects = append(rects, r.Rect)
+			} else {
+				shapes = append(shapes, c)
+			}
+		}
+	}
+
+	for _, other := range s.nearbyActors(reach, actor.Blocks) {
+		if other.Radius > 0 {
+			shapes = append(shapes, NewCircleShape(other.Clip.Center(), other.Radius))
+		} else {
+			rects = append(rects, other.Clip)
+		}
+	}
+
+	return rects, shapes
+}
+
+// step will move the clip along the movement a pixel at a time, stopping
+// short of the first shape it would hit. Shapes we already overlap, and one
+// sided walls we are behind, are ignored.
+func (s *Scene) step(actor *Actor, clip pixel.Rect, movement pixel.Vec, shapes []Shape) pixel.Vec {
+	if movement.Len() == 0 || len(shapes) == 0 {
+		return movement
+	}
+
+	// Circular actors collide as a circle around their centre.
+	hits := func(shape Shape, offset pixel.Vec) bool {
+		if actor.Radius > 0 {
+			return shape.IntersectsCircle(pixel.C(clip.Center().Add(offset), actor.Radius))
+		}
+		return shape.IntersectsRect(clip.Moved(offset))
+	}
+
+	// Only the shapes we might reach matter.
+	reach := clip.Union(clip.Moved(movement))
+	if actor.Radius > 0 {
+		reach = pixel.R(reach.Min.X-actor.Radius, reach.Min.Y-actor.Radius, reach.Max.X+actor.Radius, reach.Max.Y+actor.Radius)
+	}
+	active := make([]Shape, 0)
+	for _, shape := range shapes {
+		if !boundsTouch(shape.Bounds(), reach) || hits(shape, pixel.ZV) {
+			continue
+		}
+		if line, ok := shape.(*PolylineShape); ok && line.Passable(clip.Center()) {
+			continue
+		}
+		active = append(active, shape)
+	}
+
+	steps := math.Ceil(movement.Len())
+	stepped := pixel.ZV
+	for i := 1.0; i <= steps; i++ {
+		next := movement.Scaled(i / steps)
+		for _, shape := range active {
+			if hits(shape, next) {
+				return stepped
+			}
+		}
+		stepped = next
+	}
+
+	return stepped
+}
+
+// sweep will find how far the clip can travel along one axis before it hits
+// a blocker. Blockers we already overlap are ignored so we can move out of
+// them.
+func sweep(clip pixel.Rect, amount float64, horizontal bool, blockers []pixel.Rect) float64 {
+	// Swap our axes around so we only write this once.
+	axis := func(r pixel.Rect) (float64, float64, float64, float64) {
+		if horizontal {
+			return r.Min.X, r.Max.X, r.Min.Y, r.Max.Y
+		}
+		return r.Min.Y, r.Max.Y, r.Min.X, r.Max.X
+	}
+
+	min, max, crossMin, crossMax := axis(clip)
+	for _, b := range blockers {
+		bMin, bMax, bCrossMin, bCrossMax := axis(b)
+
+		// Blockers off to the side, or already overlapping, don't matter.
+		if bCrossMin >= crossMax || bCrossMax <= crossMin {
+			continue
+		}
+		if bMin < max && bMax > min {
+			continue
+		}
+
+		if amount > 0 && bMin >= max {
+			amount = math.Min(amount, bMin-max)
+		} else if amount < 0 && bMax <= min {
+			amount = math.Max(amount, bMax-min)
+		}
+	}
+
+	return amount
+}
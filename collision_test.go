@@ -0,0 +1,101 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorCollidesWith(t *testing.T) {
+	hero := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll}
+	npc := &Actor{Collision: true, CollisionLayer: 2, CollisionMask: CollisionAll}
+	ghost := &Actor{Collision: true, CollisionLayer: 4, CollisionMask: 4}
+	coin := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Trigger: true}
+
+	assert.False(t, hero.CollidesWith(hero), "We never collide with ourselves.")
+	assert.True(t, hero.Blocks(npc), "Solid actors should block each other.")
+	assert.False(t, ghost.CollidesWith(hero), "Masks should filter out other layers.")
+	assert.True(t, hero.CollidesWith(coin), "Triggers should still be touched.")
+	assert.False(t, hero.Blocks(coin), "Triggers should not block.")
+}
+
+func TestActorCollisionFree(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	hero := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(0, 0, 32, 32)}
+	npc := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(40, 0, 72, 32)}
+	scene.Actors["hero"] = hero
+	scene.Actors["npc"] = npc
+
+	// Count the collision events for both actors.
+	events := 0
+	testEngine.Listen("collision", "test", func(e *Event) { events++ })
+	defer testEngine.Unlisten("collision", "test")
+
+	assert.True(t, scene.ActorCollisionFree(hero, pixel.R(4, 0, 36, 32)), "We should be free to move.")
+	assert.False(t, scene.ActorCollisionFree(hero, pixel.R(10, 0, 42, 32)), "We should be blocked by the npc.")
+	assert.False(t, scene.ActorCollisionFree(hero, pixel.R(10, 0, 42, 32)), "We should still be blocked.")
+	assert.Equal(t, 2, events, "Both actors get one event when first touching.")
+}
+
+func TestResolveMovement(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), Skin: 2}
+	wall := pixel.R(40, -100, 48, 100)
+	scene.MapData = &Map{Collision: []Shape{&RectShape{Rect: wall}}}
+	hero := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(0, 0, 32, 32)}
+
+	// Heading diagonally into the wall slides up along it.
+	movement := scene.ResolveMovement(hero, pixel.V(20, 20))
+	assert.Equal(t, pixel.V(10, 20), movement, "We should stop at the wall but keep sliding.")
+
+	// A huge jump can't tunnel through.
+	movement = scene.ResolveMovement(hero, pixel.V(500, 0))
+	assert.Equal(t, pixel.V(10, 0), movement, "We should not pass through the wall.")
+
+	// Without collision we go wherever we like.
+	hero.Collision = false
+	movement = scene.ResolveMovement(hero, pixel.V(500, 0))
+	assert.Equal(t, pixel.V(500, 0), movement, "We should ignore the wall.")
+}
+
+func TestResolveMovementContacts(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	hero := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(0, 0, 32, 32)}
+	npc := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(40, 0, 72, 32)}
+	coin := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Trigger: true, Clip: pixel.R(80, 0, 90, 32)}
+	scene.Actors["hero"] = hero
+	scene.Actors["npc"] = npc
+	scene.Actors["coin"] = coin
+
+	collisions, triggers := 0, 0
+	testEngine.Listen("collision", "test", func(e *Event) { collisions++ })
+	defer testEngine.Unlisten("collision", "test")
+	testEngine.Listen("trigger", "test", func(e *Event) { triggers++ })
+	defer testEngine.Unlisten("trigger", "test")
+
+	// We wanted to reach the coin, but the npc stops us against it.
+	movement := scene.ResolveMovement(hero, pixel.V(60, 0))
+	assert.Equal(t, pixel.V(8, 0), movement, "We should stop at the npc.")
+	assert.Equal(t, 2, collisions, "Bumping into the npc should be reported.")
+	assert.Equal(t, 0, triggers, "The coin behind the npc was never reached.")
+}
+
+func TestDestinationStall(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), Basespeed: 100}
+	scene.MapData = &Map{Collision: []Shape{&RectShape{Rect: pixel.R(20, -100, 28, 100)}}}
+	hero := newTestActor("hero", pixel.ZV)
+	hero.Speed, hero.Collision = 1, true
+	hero.Destinations = []pixel.Vec{pixel.V(100, 0), pixel.V(0, 50)}
+	scene.Actors["hero"] = hero
+
+	dt := testEngine.Dt
+	testEngine.Dt = 0.1
+	defer func() { testEngine.Dt = dt }()
+
+	// We walk up to the wall, then give up once we stop getting closer.
+	for i := 0; i < 3; i++ {
+		scene.ProcessActorDestinations()
+	}
+	assert.Equal(t, pixel.V(15, 0), hero.Position, "We should be up against the wall.")
+	assert.Equal(t, []pixel.Vec{pixel.V(0, 50)}, hero.Destinations, "We should move on from the blocked destination.")
+}
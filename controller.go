@@ -7,17 +7,74 @@ import (
 )
 
 // Controller manages the key handlers, running handler methods as appropriate.
-// The current state of the system will depend on what handlers are present. If
-// there are any system handlers present, they will override the application
-// handlers until there are not any system handlers present.
+// Handlers are grouped by input context, and the contexts are kept in an
+// ordered stack. Input is offered to the top context first, and travels down
+// the stack until a context consumes it. Contexts without any handlers are
+// passed over, so an empty "system" context will not block "app" handlers.
 type Controller struct {
-	// Handlers are the collections of Handler Sets
+	// Handlers are the collections of Handler Sets, keyed by context.
 	Handlers map[string][]*Handler
 
+	// Contexts is the stack of active input contexts. The last context is
+	// the top of the stack and receives input first.
+	Contexts []*InputContext
+
+	// Text is the running text input, which takes all input while set.
+	Text *TextInput
+
 	// Engine is the engine the controller is running on.
 	Engine *Engine
+
+	// Buttons is where button states are read from, the engine window when
+	// not set.
+	Buttons ButtonState
+}
+
+// ButtonState reports what the buttons are doing this cycle, as the window
+// does.
+type ButtonState interface {
+	Pressed(button pixelgl.Button) bool
+	JustPressed(button pixelgl.Button) bool
+	JustReleased(button pixelgl.Button) bool
 }
 
+// InputContext is a named layer of input, such as a console, dialog, menu or
+// gameplay. Scenes and widgets push their context when they need input and
+// pop it when they are done.
+type InputContext struct {
+	// ID is the context name, matching the class used for its handlers.
+	ID string
+
+	// Passthrough will let input continue to the contexts below this one
+	// after it has been processed. Otherwise this context consumes it.
+	Passthrough bool
+}
+
+// Trigger is the kind of button activity that will fire a handler.
+type Trigger int
+
+const (
+	// TriggerHeld fires every cycle while the button is held down.
+	TriggerHeld Trigger = iota
+
+	// TriggerPressed fires once when the button is first pressed.
+	TriggerPressed
+
+	// TriggerReleased fires once when the button is let go.
+	TriggerReleased
+
+	// TriggerHeldFor fires once the button has been held for Duration.
+	TriggerHeldFor
+
+	// TriggerDoubleTap fires when the button is pressed twice within
+	// Duration.
+	TriggerDoubleTap
+
+	// TriggerRepeat fires on press, then again after Delay, repeating every
+	// Interval while the button stays down. Much like typing key repeat.
+	TriggerRepeat
+)
+
 // Handler is our structure that we will create and add to the controller
 type Handler struct {
 	// ID will be a string we can use to identify a handler when we need to
@@ -27,11 +84,36 @@ type Handler struct {
 	// The keypress we are checking
 	Button pixelgl.Button
 
-	// Sensitive will indicate if we JustPress...usually for menus
+	// Chord are extra buttons that must be held along with Button for the
+	// handler to fire, such as a modifier key.
+	Chord []pixelgl.Button
+
+	// Sensitive will indicate if we JustPress...usually for menus. This is
+	// the same as using TriggerPressed.
 	Sensitive bool
 
+	// Trigger is the kind of button activity that fires the handler.
+	Trigger Trigger
+
+	// Duration is the hold time for TriggerHeldFor, and the tap window for
+	// TriggerDoubleTap, in seconds.
+	Duration float64
+
+	// Delay is the wait before TriggerRepeat starts repeating, in seconds.
+	Delay float64
+
+	// Interval is the time between TriggerRepeat repeats, in seconds.
+	Interval float64
+
 	// The action to perform
 	Action func()
+
+	// Timing state used to track held, tapped and repeating buttons.
+	held    float64
+	next    float64
+	lastTap float64
+	tapped  bool
+	fired   bool
 }
 
 // Initialize will setup any structure elements that require not being nil.
@@ -39,6 +121,11 @@ func (c *Controller) Initialize() {
 	// Setup handler map
 	c.Handlers = make(map[string][]*Handler)
 
+	// Our default contexts, system handlers overrule the application.
+	c.Contexts = make([]*InputContext, 0)
+	c.PushContext("app", false)
+	c.PushContext("system", false)
+
 	// Set the starting time.
 	c.Engine.LastMove = time.Now()
 }
@@ -48,12 +135,17 @@ func (c *Controller) Initialize() {
 // once. Otherwise the handler will act as a game button, allowing it to be
 // held for repeated effect.
 func (c *Controller) AddHandler(class string, id string, button pixelgl.Button, sensitive bool, action func()) {
-	handler, ok := c.Handlers[class]
+	c.AttachHandler(class, &Handler{ID: id, Button: button, Sensitive: sensitive, Action: action})
+}
+
+// AttachHandler will add a fully configured handler to the given context.
+// This is how to setup handlers with triggers beyond pressed and held.
+func (c *Controller) AttachHandler(class string, handler *Handler) {
+	handlers, ok := c.Handlers[class]
 	if !ok {
-		handler = make([]*Handler, 0)
+		handlers = make([]*Handler, 0)
 	}
-	handler = append(handler, &Handler{ID: id, Button: button, Sensitive: sensitive, Action: action})
-	c.Handlers[class] = handler
+	c.Handlers[class] = append(handlers, handler)
 }
 
 // RemoveHandler will remove a handler from the provided handler list.
@@ -72,31 +164,175 @@ func (c *Controller) RemoveHandler(class string, id string) {
 	c.Handlers[class] = newHandlers
 }
 
+// PushContext will put the context on top of the stack. If the context is
+// already on the stack, it is moved to the top.
+func (c *Controller) PushContext(id string, passthrough bool) {
+	c.RemoveContext(id)
+	c.Contexts = append(c.Contexts, &InputContext{ID: id, Passthrough: passthrough})
+}
+
+// InsertContext will put the context just below another context, such as
+// keeping a scene under "system". The context goes on top when the other
+// context isn't on the stack.
+func (c *Controller) InsertContext(id string, passthrough bool, below string) {
+	c.RemoveContext(id)
+	ctx := &InputContext{ID: id, Passthrough: passthrough}
+	for i, existing := range c.Contexts {
+		if existing.ID == below {
+			c.Contexts = append(c.Contexts[:i], append([]*InputContext{ctx}, c.Contexts[i:]...)...)
+			return
+		}
+	}
+	c.Contexts = append(c.Contexts, ctx)
+}
+
+// PopContext will remove the top context from the stack, returning its id.
+// An empty string is returned when there are no contexts.
+func (c *Controller) PopContext() string {
+	if len(c.Contexts) == 0 {
+		return ""
+	}
+
+	top := c.Contexts[len(c.Contexts)-1]
+	c.Contexts = c.Contexts[:len(c.Contexts)-1]
+
+	return top.ID
+}
+
+// RemoveContext will take the context out of the stack, wherever it sits.
+func (c *Controller) RemoveContext(id string) {
+	newContexts := make([]*InputContext, 0)
+	for _, ctx := range c.Contexts {
+		if ctx.ID != id {
+			newContexts = append(newContexts, ctx)
+		}
+	}
+	c.Contexts = newContexts
+}
+
+// HasContext will indicate if the context is on the stack.
+func (c *Controller) HasContext(id string) bool {
+	for _, ctx := range c.Contexts {
+		if ctx.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // Run will loop through our controllers running any handlers that are setup.
 func (c *Controller) Run() {
 	// Manage timing
 	c.Engine.Dt = time.Since(c.Engine.LastMove).Seconds()
 	c.Engine.LastMove = time.Now()
+	c.Engine.Time += c.Engine.Dt
+
+	// Text entry takes priority over every context.
+	if c.Text != nil {
+		c.processText()
+		return
+	}
+
+	// Work down from the top of the stack until something consumes input.
+	for i := len(c.Contexts) - 1; i >= 0; i-- {
+		ctx := c.Contexts[i]
+		handlers := c.Handlers[ctx.ID]
+		if len(handlers) == 0 {
+			continue
+		}
 
-	// If we have system handlers, we overrule application handlers.
-	if len(c.Handlers["system"]) > 0 {
-		c.processHandlers(c.Handlers["system"])
-	} else {
-		c.processHandlers(c.Handlers["app"])
+		c.processHandlers(handlers)
+
+		if !ctx.Passthrough {
+			break
+		}
 	}
 }
 
 // This should likely not be used externally yet, if at all.
 func (c *Controller) processHandlers(handlers []*Handler) {
 	for _, h := range handlers {
-		if h.Sensitive {
-			if c.Engine.win.JustPressed(h.Button) {
-				h.Action()
-			}
-		} else {
-			if c.Engine.win.Pressed(h.Button) {
-				h.Action()
-			}
+		if c.triggered(h) {
+			h.Action()
+		}
+	}
+}
+
+// triggered will check the handler button state against its trigger,
+// updating the handler timing along the way.
+func (c *Controller) triggered(h *Handler) bool {
+	win := c.buttons()
+	chord := c.chordHeld(h)
+	down := win.Pressed(h.Button) && chord
+	pressed := win.JustPressed(h.Button) && chord
+
+	trigger := h.Trigger
+	if h.Sensitive {
+		trigger = TriggerPressed
+	}
+
+	switch trigger {
+	case TriggerPressed:
+		return pressed
+	case TriggerReleased:
+		return win.JustReleased(h.Button) && chord
+	case TriggerHeldFor:
+		if !down {
+			h.held = 0
+			h.fired = false
+			return false
+		}
+		h.held += c.Engine.Dt
+		if !h.fired && h.held >= h.Duration {
+			h.fired = true
+			return true
+		}
+		return false
+	case TriggerDoubleTap:
+		if !pressed {
+			return false
+		}
+		if h.tapped && c.Engine.Time-h.lastTap <= h.Duration {
+			h.tapped = false
+			return true
+		}
+		h.tapped = true
+		h.lastTap = c.Engine.Time
+		return false
+	case TriggerRepeat:
+		if pressed {
+			h.held = 0
+			h.next = h.Delay
+			return true
 		}
+		if !down {
+			return false
+		}
+		h.held += c.Engine.Dt
+		if h.held >= h.next {
+			h.next += h.Interval
+			return true
+		}
+		return false
+	default:
+		return down
+	}
+}
+
+// chordHeld will indicate if all of the chord buttons are held down.
+func (c *Controller) chordHeld(h *Handler) bool {
+	for _, b := range h.Chord {
+		if !c.buttons().Pressed(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// buttons will return where button states are read from.
+func (c *Controller) buttons() ButtonState {
+	if c.Buttons != nil {
+		return c.Buttons
 	}
+	return c.Engine.win
 }
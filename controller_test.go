@@ -0,0 +1,186 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeButtons is a button state we set by hand, remembering what was down
+// last cycle to work out presses and releases.
+type fakeButtons struct {
+	down map[pixelgl.Button]bool
+	last map[pixelgl.Button]bool
+}
+
+func newFakeButtons() *fakeButtons {
+	return &fakeButtons{down: make(map[pixelgl.Button]bool), last: make(map[pixelgl.Button]bool)}
+}
+
+// set will start a new cycle with the given buttons held down.
+func (f *fakeButtons) set(buttons ...pixelgl.Button) {
+	f.last = f.down
+	f.down = make(map[pixelgl.Button]bool)
+	for _, b := range buttons {
+		f.down[b] = true
+	}
+}
+
+func (f *fakeButtons) Pressed(b pixelgl.Button) bool      { return f.down[b] }
+func (f *fakeButtons) JustPressed(b pixelgl.Button) bool  { return f.down[b] && !f.last[b] }
+func (f *fakeButtons) JustReleased(b pixelgl.Button) bool { return !f.down[b] && f.last[b] }
+
+// newFakeController will make a controller on its own engine, reading the
+// fake buttons.
+func newFakeController() (*Controller, *fakeButtons) {
+	buttons := newFakeButtons()
+	e := &Engine{Scenes: make(map[string]*Scene)}
+	e.Control = &Controller{Engine: e, Buttons: buttons}
+	e.Control.Initialize()
+	return e.Control, buttons
+}
+
+// cycle will step the controller clock and check the handler against the
+// buttons held down.
+func cycle(c *Controller, h *Handler, dt float64, buttons *fakeButtons, held ...pixelgl.Button) bool {
+	buttons.set(held...)
+	c.Engine.Dt = dt
+	c.Engine.Time += dt
+	return c.triggered(h)
+}
+
+func TestControllerContexts(t *testing.T) {
+	c := &Controller{Engine: testEngine}
+	c.Initialize()
+
+	// We start with our application and system contexts.
+	assert.Equal(t, 2, len(c.Contexts), "We should have 2 default contexts.")
+	assert.True(t, c.HasContext("app"), "We should have an app context.")
+
+	// Pushing a context puts it on top.
+	c.PushContext("menu", true)
+	assert.Equal(t, "menu", c.Contexts[len(c.Contexts)-1].ID, "Menu should be on top.")
+
+	// Pushing an existing context moves it to the top.
+	c.PushContext("app", false)
+	assert.Equal(t, 3, len(c.Contexts), "We should not duplicate contexts.")
+	assert.Equal(t, "app", c.PopContext(), "App should have been on top.")
+
+	// Removing from within the stack.
+	c.RemoveContext("system")
+	assert.False(t, c.HasContext("system"), "System context should be removed.")
+	assert.Equal(t, "menu", c.PopContext(), "Menu should be all that is left.")
+	assert.Equal(t, "", c.PopContext(), "An empty stack pops nothing.")
+}
+
+func TestControllerSceneContext(t *testing.T) {
+	c, buttons := newFakeController()
+	c.Engine.Scenes["town"] = &Scene{Context: "town"}
+	c.Engine.ActivateScene("town")
+	assert.Equal(t, "system", c.Contexts[len(c.Contexts)-1].ID, "System should stay on top of scenes.")
+	assert.Equal(t, "town", c.Contexts[len(c.Contexts)-2].ID, "The scene should sit just below system.")
+
+	// System handlers, such as closing a message box, get input first.
+	system, town := 0, 0
+	c.AddHandler("system", "close", pixelgl.KeyEnter, true, func() { system++ })
+	c.AddHandler("town", "talk", pixelgl.KeyEnter, true, func() { town++ })
+	buttons.set(pixelgl.KeyEnter)
+	c.Run()
+	assert.Equal(t, 1, system, "The system handler should fire.")
+	assert.Equal(t, 0, town, "System should consume the input.")
+
+	// Without system handlers the scene gets its turn.
+	c.RemoveHandler("system", "close")
+	buttons.set()
+	buttons.set(pixelgl.KeyEnter)
+	c.Run()
+	assert.Equal(t, 1, town, "The scene handler should fire.")
+}
+
+func TestControllerInsertContext(t *testing.T) {
+	c, _ := newFakeController()
+	c.InsertContext("menu", true, "app")
+	assert.Equal(t, "menu", c.Contexts[0].ID, "Menu should go below app.")
+
+	c.InsertContext("menu", false, "missing")
+	assert.Equal(t, "menu", c.Contexts[len(c.Contexts)-1].ID, "Menu should go on top without the context.")
+	assert.Equal(t, 3, len(c.Contexts), "We should not duplicate contexts.")
+}
+
+func TestTriggerPressedAndHeld(t *testing.T) {
+	c, buttons := newFakeController()
+	pressed := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerPressed}
+	held := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerHeld}
+
+	buttons.set(pixelgl.KeySpace)
+	assert.True(t, c.triggered(pressed), "Pressed fires on the first cycle.")
+	assert.True(t, c.triggered(held), "Held fires on the first cycle.")
+
+	buttons.set(pixelgl.KeySpace)
+	assert.False(t, c.triggered(pressed), "Pressed only fires once.")
+	assert.True(t, c.triggered(held), "Held keeps firing.")
+
+	buttons.set()
+	assert.False(t, c.triggered(held), "Held stops once let go.")
+}
+
+func TestTriggerReleased(t *testing.T) {
+	c, buttons := newFakeController()
+	h := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerReleased}
+
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "Nothing fires while held.")
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace))
+	assert.True(t, cycle(c, h, 0.1, buttons), "Letting go fires.")
+	assert.False(t, cycle(c, h, 0.1, buttons), "Releasing only fires once.")
+}
+
+func TestTriggerHeldFor(t *testing.T) {
+	c, buttons := newFakeController()
+	h := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerHeldFor, Duration: 1}
+
+	assert.False(t, cycle(c, h, 0.5, buttons, pixelgl.KeySpace))
+	assert.True(t, cycle(c, h, 0.5, buttons, pixelgl.KeySpace), "Fires once held long enough.")
+	assert.False(t, cycle(c, h, 0.5, buttons, pixelgl.KeySpace), "Only fires once per hold.")
+
+	// Letting go starts over.
+	assert.False(t, cycle(c, h, 0.5, buttons))
+	assert.False(t, cycle(c, h, 0.5, buttons, pixelgl.KeySpace))
+	assert.True(t, cycle(c, h, 0.5, buttons, pixelgl.KeySpace))
+}
+
+func TestTriggerDoubleTap(t *testing.T) {
+	c, buttons := newFakeController()
+	h := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerDoubleTap, Duration: 0.3}
+
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "One tap isn't enough.")
+	assert.False(t, cycle(c, h, 0.1, buttons))
+	assert.True(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "A quick second tap fires.")
+
+	// Taps too far apart don't count.
+	assert.False(t, cycle(c, h, 0.1, buttons))
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace))
+	assert.False(t, cycle(c, h, 0.5, buttons))
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "A slow second tap starts over.")
+}
+
+func TestTriggerRepeat(t *testing.T) {
+	c, buttons := newFakeController()
+	h := &Handler{Button: pixelgl.KeySpace, Trigger: TriggerRepeat, Delay: 0.5, Interval: 0.2}
+
+	assert.True(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "Fires on press.")
+	assert.False(t, cycle(c, h, 0.3, buttons, pixelgl.KeySpace), "Waits for the delay.")
+	assert.True(t, cycle(c, h, 0.2, buttons, pixelgl.KeySpace), "Repeats after the delay.")
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace))
+	assert.True(t, cycle(c, h, 0.1, buttons, pixelgl.KeySpace), "Repeats every interval.")
+	assert.False(t, cycle(c, h, 0.5, buttons), "Stops once let go.")
+}
+
+func TestTriggerChord(t *testing.T) {
+	c, buttons := newFakeController()
+	h := &Handler{Button: pixelgl.KeyS, Chord: []pixelgl.Button{pixelgl.KeyLeftControl}, Trigger: TriggerPressed}
+
+	assert.False(t, cycle(c, h, 0.1, buttons, pixelgl.KeyS), "The chord must be held.")
+	assert.False(t, cycle(c, h, 0.1, buttons))
+	assert.True(t, cycle(c, h, 0.1, buttons, pixelgl.KeyS, pixelgl.KeyLeftControl), "Fires with the chord held.")
+}
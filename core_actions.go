@@ -1,7 +1,11 @@
 package gamesys
 
 import (
+	"errors"
+	"image/color"
+
 	"github.com/faiface/pixel"
+	"golang.org/x/image/colornames"
 )
 
 // CreateCoreActions sets up the basic scripting actions that will
@@ -142,9 +146,7 @@ func (e *Engine) CreateCoreActions() {
 		e.AddActor(id, newActor)
 
 		// Use this actor on the scene.
-		scene.UseActor(id)
-
-		return nil
+		return scene.UseActor(id)
 	})
 	e.ScriptActions[newScript.Action] = newScript
 
@@ -255,4 +257,786 @@ func (e *Engine) CreateCoreActions() {
 		return nil
 	})
 	e.ScriptActions[newScript.Action] = newScript
+
+	// ***********************************************************************
+	// TextInput will prompt for typed text, storing it into a script variable
+	// once entered. The variable is left alone if entry is cancelled. Class
+	// can be alpha, numeric, alnum, name or any.
+	//
+	// The script carries on without waiting, so only scripts run after entry
+	// see the variable. Arguments like $name are replaced by the variable
+	// once it is set, and left as they are until then.
+	// =======================================================================
+	// TextInput variable maxlength class prompt...
+	// -----------------------------------------------------------------------
+	newScript = NewScriptAction("TextInput", func(args []interface{}) interface{} {
+		// Setup arguments.
+		variable := args[0].(string)
+		maxlength := int(StrFloat(args[1]))
+		class := args[2].(string)
+
+		// The rest of our arguments make up the prompt.
+		prompt := ""
+		for i, a := range args[3:] {
+			if i > 0 {
+				prompt += " "
+			}
+			prompt += a.(string)
+		}
+
+		input := NewTextInput(maxlength, class)
+		input.OnSubmit = func(value string) {
+			e.SetVariable(variable, value)
+		}
+
+		e.DisplayTextInput(prompt, input)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// *********************************************************************
+	// ActorAnimations will load an animation file from the characters
+	// directory for the actor, switching it to automatic idle and walking.
+	// =====================================================================
+	// ActorAnimations scene_id actor_id file
+	// ---------------------------------------------------------------------
+	newScript = NewScriptAction("ActorAnimations", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		file := args[2].(string)
+
+		return e.LoadActorAnimations(actor, file)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// *********************************************************************
+	// ActorAnimation will play the named animation on the actor. Using auto
+	// as the name hands control back to automatic idle and walking.
+	// =====================================================================
+	// ActorAnimation scene_id actor_id animation
+	// ---------------------------------------------------------------------
+	newScript = NewScriptAction("ActorAnimation", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		animation := args[2].(string)
+
+		if animation == "auto" {
+			actor.AutoAnimate = true
+			return nil
+		}
+
+		actor.AutoAnimate = false
+		actor.Play(animation, e.Time)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// *******************************************************************
+	// ActorFacing will turn the actor to face a direction in degrees, with
+	// 0 being right and 90 being up.
+	// ===================================================================
+	// ActorFacing scene_id actor_id direction
+	// -------------------------------------------------------------------
+	newScript = NewScriptAction("ActorFacing", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		direction := int(StrFloat(args[2]))
+
+		actor.Face(direction)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ******************************************************************
+	// SceneMovement will set how actors move on the scene. The mode is one
+	// of free, 8way, 4way or grid.
+	// ==================================================================
+	// SceneMovement scene_id mode
+	// ------------------------------------------------------------------
+	newScript = NewScriptAction("SceneMovement", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene, err := e.findScene(args[0].(string))
+		if err != nil {
+			return err
+		}
+		mode := args[1].(string)
+
+		scene.Movement = ParseMovementMode(mode)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ***********************************************************************
+	// ActorCollision will set which collision layers the actor sits on and
+	// reacts to, as bit values. Trigger actors report contact without
+	// blocking.
+	// =======================================================================
+	// ActorCollision scene_id actor_id layer mask trigger
+	// -----------------------------------------------------------------------
+	newScript = NewScriptAction("ActorCollision", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		layer := uint32(StrFloat(args[2]))
+		mask := uint32(StrFloat(args[3]))
+		trigger := StrBool(args[4])
+
+		actor.CollisionLayer = layer
+		actor.CollisionMask = mask
+		actor.Trigger = trigger
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// **********************************************************************
+	// PathActor will send the actor to the destination along a path found
+	// around the map collision. Diagonal allows diagonal steps, and smooth
+	// straightens the path out. An error is returned if there is no way
+	// there.
+	// ======================================================================
+	// PathActor scene_id actor_id x y diagonal smooth
+	// ----------------------------------------------------------------------
+	newScript = NewScriptAction("PathActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		scene := e.Scenes[args[0].(string)]
+		x := StrFloat(args[2])
+		y := StrFloat(args[3])
+		diagonal := StrBool(args[4])
+		smooth := StrBool(args[5])
+
+		return scene.PathActor(actor, pixel.V(x, y), PathOptions{Diagonal: diagonal, Smooth: smooth, Actors: true})
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ***********************************************************************
+	// ActorBehaviour will switch the behaviour driving the actor. The options
+	// depend on the behaviour, and none removes any behaviour.
+	// =======================================================================
+	// ActorBehaviour scene_id actor_id none
+	// ActorBehaviour scene_id actor_id wander radius pause
+	// ActorBehaviour scene_id actor_id patrol map_object pingpong
+	// ActorBehaviour scene_id actor_id follow target_id distance
+	// ActorBehaviour scene_id actor_id flee target_id distance
+	// -----------------------------------------------------------------------
+	newScript = NewScriptAction("ActorBehaviour", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		scene := e.Scenes[args[0].(string)]
+		kind := args[2].(string)
+
+		// Name our options the same as the Tiled properties.
+		options := make(map[string]string)
+		names := map[string][]string{
+			"wander": {"wanderRadius", "pause"},
+			"patrol": {"patrol", "pingpong"},
+			"follow": {"target", "distance"},
+			"flee":   {"target", "distance"},
+		}
+		for i, name := range names[kind] {
+			if i+3 < len(args) {
+				options[name] = args[i+3].(string)
+			}
+		}
+
+		behaviour, err := scene.NewBehaviour(actor, kind, options)
+		if err != nil {
+			return err
+		}
+
+		actor.Behaviour = behaviour
+		actor.Destinations = nil
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// *********************************************************************
+	// ActorProperty will set a custom property on the actor. The type is
+	// one of string, int, float or bool.
+	// =====================================================================
+	// ActorProperty scene_id actor_id name type value
+	// ---------------------------------------------------------------------
+	newScript = NewScriptAction("ActorProperty", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		name := args[2].(string)
+		kind := args[3].(string)
+		value := args[4].(string)
+
+		actor.SetProperty(name, ParseProperty(kind, value))
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ************************************
+	// ActorTag will add tags to the actor.
+	// ====================================
+	// ActorTag scene_id actor_id tags...
+	// ------------------------------------
+	newScript = NewScriptAction("ActorTag", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+
+		for _, t := range args[2:] {
+			actor.Tag(t.(string))
+		}
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************
+	// ActorUntag will remove tags from the actor.
+	// ============================================
+	// ActorUntag scene_id actor_id tags...
+	// --------------------------------------------
+	newScript = NewScriptAction("ActorUntag", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+
+		for _, t := range args[2:] {
+			actor.Untag(t.(string))
+		}
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// LoadTemplates will load actor templates from an xml file.
+	// ====================================================================
+	// LoadTemplates file
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("LoadTemplates", func(args []interface{}) interface{} {
+		// Setup arguments.
+		file := args[0].(string)
+
+		return e.LoadTemplates(file)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// SpawnActor will create an actor from a template on the scene. Any
+	// template field can be overridden, and other overrides are set as
+	// properties.
+	// ====================================================================
+	// SpawnActor scene_id actor_id template x y key=value...
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("SpawnActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene, err := e.findScene(args[0].(string))
+		if err != nil {
+			return err
+		}
+		id := args[1].(string)
+		template := args[2].(string)
+		x := StrFloat(args[3])
+		y := StrFloat(args[4])
+		overrides := ParseOverrides(args[5:])
+
+		_, err = e.SpawnActor(scene, id, template, pixel.V(x, y), overrides)
+		return err
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorLayer will set the draw layer of the actor. Higher layers are
+	// drawn over lower ones.
+	// ====================================================================
+	// ActorLayer scene_id actor_id layer
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorLayer", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		layer := int(StrFloat(args[2]))
+
+		actor.Layer = layer
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ViewYSort will turn height sorting of actors on or off for the view.
+	// ====================================================================
+	// ViewYSort scene_id view_id true|false
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ViewYSort", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		view := args[1].(string)
+		ysort := StrBool(args[2])
+
+		e.Scenes[scene].Views[view].YSort = ysort
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorScale will resize the actor, with 1 being the normal size. A
+	// single scale is used for both directions.
+	// ====================================================================
+	// ActorScale scene_id actor_id scale [scale_y]
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorScale", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		x := StrFloat(args[2])
+		y := x
+		if len(args) > 3 {
+			y = StrFloat(args[3])
+		}
+
+		actor.SetScale(pixel.V(x, y))
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorRotation will rotate the actor, in degrees counter clockwise.
+	// ====================================================================
+	// ActorRotation scene_id actor_id degrees
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorRotation", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		rotation := StrFloat(args[2])
+
+		actor.SetRotation(rotation)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorOrigin will set the point the actor scales and rotates around,
+	// relative to the centre of the actor.
+	// ====================================================================
+	// ActorOrigin scene_id actor_id x y
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorOrigin", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		x := StrFloat(args[2])
+		y := StrFloat(args[3])
+
+		actor.SetOrigin(pixel.V(x, y))
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorFlip will mirror the actor across and up and down.
+	// ====================================================================
+	// ActorFlip scene_id actor_id true|false true|false
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorFlip", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		x := StrBool(args[2])
+		y := StrBool(args[3])
+
+		actor.Flip(x, y)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorTint will set the color the actor is multiplied by, either by
+	// name or red, green and blue from 0 to 255.
+	// ====================================================================
+	// ActorTint scene_id actor_id color|red green blue
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorTint", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		tint, ok := colornames.Map[args[2].(string)]
+		if !ok && len(args) > 4 {
+			tint = color.RGBA{uint8(StrFloat(args[2])), uint8(StrFloat(args[3])), uint8(StrFloat(args[4])), 255}
+		}
+
+		actor.Tint = tint
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorAlpha will set how opaque the actor is, from 0 to 1.
+	// ====================================================================
+	// ActorAlpha scene_id actor_id alpha
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorAlpha", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		alpha := StrFloat(args[2])
+
+		actor.SetAlpha(alpha)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// TweenActor will smoothly change the actor scale, rotation, alpha or
+	// tint over the duration in seconds. Ease is linear, in, out or inout,
+	// and yoyo heads back to the start once there. Tints take a color name
+	// or red, green and blue.
+	// ====================================================================
+	// TweenActor scene_id actor_id property duration ease yoyo values...
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("TweenActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		property := args[2].(string)
+		duration := StrFloat(args[3])
+		ease := ParseEase(args[4].(string))
+		yoyo := StrBool(args[5])
+
+		values := make([]float64, 0)
+		if tint, ok := colornames.Map[args[6].(string)]; ok {
+			values = append(values, float64(tint.R), float64(tint.G), float64(tint.B))
+		} else {
+			for _, v := range args[6:] {
+				values = append(values, StrFloat(v))
+			}
+		}
+
+		_, err = e.TweenActor(actor, property, values, duration, ease, yoyo)
+		return err
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorAttach will attach the actor to a parent on the same scene at the
+	// offset, so it follows the parent around.
+	// ====================================================================
+	// ActorAttach scene_id actor_id parent_id x y
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorAttach", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		parent, err := e.sceneActor(args[0].(string), args[2].(string))
+		if err != nil {
+			return err
+		}
+		x := StrFloat(args[3])
+		y := StrFloat(args[4])
+
+		return actor.Attach(parent, pixel.V(x, y))
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorDetach will drop the actor from its parent.
+	// ====================================================================
+	// ActorDetach scene_id actor_id
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorDetach", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+
+		actor.Detach()
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// SaveState will save the game state to a file.
+	// ====================================================================
+	// SaveState file
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("SaveState", func(args []interface{}) interface{} {
+		// Setup arguments.
+		file := args[0].(string)
+
+		return e.SaveState(file)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// LoadState will load the game state from a file.
+	// ====================================================================
+	// LoadState file
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("LoadState", func(args []interface{}) interface{} {
+		// Setup arguments.
+		file := args[0].(string)
+
+		return e.LoadState(file)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// RemoveActor will despawn the actor, removing it from every scene and
+	// the engine. Children are removed with it.
+	// ====================================================================
+	// RemoveActor actor_id
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("RemoveActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor := args[0].(string)
+
+		return e.RemoveActor(actor)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// SceneRemoveActor will take the actor off the scene, keeping it on the
+	// engine to be used again.
+	// ====================================================================
+	// SceneRemoveActor scene_id actor_id
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("SceneRemoveActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		actor := args[1].(string)
+		if _, err := e.sceneActor(scene, actor); err != nil {
+			return err
+		}
+
+		e.Scenes[scene].RemoveActor(actor)
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// UseActor will use an existing actor on the scene.
+	// ====================================================================
+	// UseActor scene_id actor_id
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("UseActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		actor := args[1].(string)
+
+		return e.Scenes[scene].UseActor(actor)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// TransferActor will move the actor to another scene, keeping its
+	// state, placing it at the position and showing it on the views.
+	// ====================================================================
+	// TransferActor actor_id from_scene_id to_scene_id x y views_id...
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("TransferActor", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor := args[0].(string)
+		from := args[1].(string)
+		to := args[2].(string)
+		x := StrFloat(args[3])
+		y := StrFloat(args[4])
+		views := make([]string, 0)
+		for _, v := range args[5:] {
+			views = append(views, v.(string))
+		}
+
+		return e.TransferActor(actor, from, to, pixel.V(x, y), views...)
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// Interact will have the actor interact with whatever is in front of
+	// it on the scene.
+	// ====================================================================
+	// Interact scene_id actor_id
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("Interact", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		actor := args[1].(string)
+
+		return e.Scenes[scene].Interact(e.Actors[actor])
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorReach will set how far in front the actor can interact.
+	// ====================================================================
+	// ActorReach scene_id actor_id reach
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorReach", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		reach := StrFloat(args[2])
+
+		actor.Reach = reach
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// LayerVisible will show or hide a map layer on the scene.
+	// ====================================================================
+	// LayerVisible scene_id layer true|false
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("LayerVisible", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		name := args[1].(string)
+		visible := StrBool(args[2])
+
+		layer, err := e.Scenes[scene].MapData.Layer(name)
+		if err != nil {
+			return err
+		}
+		layer.Visible = visible
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// LayerOpacity will set how opaque a map layer is, from 0 to 1.
+	// ====================================================================
+	// LayerOpacity scene_id layer opacity
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("LayerOpacity", func(args []interface{}) interface{} {
+		// Setup arguments.
+		scene := args[0].(string)
+		name := args[1].(string)
+		opacity := StrFloat(args[2])
+
+		layer, err := e.Scenes[scene].MapData.Layer(name)
+		if err != nil {
+			return err
+		}
+		layer.Opacity = opacity
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// ActorRadius will make the actor collide as a circle of the radius,
+	// or by its clip again with a radius of 0.
+	// ====================================================================
+	// ActorRadius scene_id actor_id radius
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("ActorRadius", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		radius := StrFloat(args[2])
+
+		actor.Radius = radius
+
+		return nil
+	})
+	e.ScriptActions[newScript.Action] = newScript
+
+	// ********************************************************************
+	// Warp will send the actor and its party to a spawn point on another
+	// scene, or on a map file when there's no scene by that name. The target
+	// can be - to stay on the scene, and the spawn - to stay put.
+	// ====================================================================
+	// Warp scene_id actor_id target spawn [facing] [transition] [duration]
+	// --------------------------------------------------------------------
+	newScript = NewScriptAction("Warp", func(args []interface{}) interface{} {
+		// Setup arguments.
+		actor, err := e.sceneActor(args[0].(string), args[1].(string))
+		if err != nil {
+			return err
+		}
+		if len(args) == 6 {
+			return errors.New("warp: transition " + args[5].(string) + " needs a duration")
+		}
+
+		w := &Warp{}
+		if args[2] != "-" {
+			w.Scene = args[2].(string)
+		}
+		if args[3] != "-" {
+			w.Spawn = args[3].(string)
+		}
+		if _, ok := e.Scenes[w.Scene]; !ok {
+			w.Scene, w.Map = "", w.Scene
+		}
+		if len(args) > 4 {
+			w.Facing, w.Turn = ParseFacing(args[4].(string)), true
+		}
+		if len(args) > 6 {
+			w.Transition = args[5].(string)
+			w.Duration = StrFloat(args[6])
+		}
+
+		return e.Scenes[args[0].(string)].Warp(actor, w)
+	})
+	e.ScriptActions[newScript.Action] = newScript
 }
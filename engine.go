@@ -3,6 +3,7 @@ package gamesys
 import (
 	"errors"
 	"fmt"
+	"image/color"
 	"os"
 	"time"
 
@@ -28,6 +29,10 @@ type Engine struct {
 	// ScriptActions holds defined scripting actions.
 	ScriptActions map[string]*ScriptAction
 
+	// Variables holds script variables. Script arguments written as `$name`
+	// are replaced with the variable value when the action runs.
+	Variables map[string]string
+
 	// Font is our basic text atlas for system purposes.
 	Font *text.Atlas
 
@@ -37,11 +42,35 @@ type Engine struct {
 	// ActiveScene is the currently running scene.
 	ActiveScene *Scene
 
+	// Tweens are the running actor tweens.
+	Tweens []*Tween
+
+	// Transition is the screen transition playing, if any.
+	Transition *Transition
+
+	// Interactions are the Go interaction handlers, by actor id or map object
+	// name.
+	Interactions map[string]func(target *Interactable, by *Actor)
+
+	// InteractPrompt is shown when something can be interacted with. No
+	// prompt is shown when empty.
+	InteractPrompt string
+
+	// interactHandler is the bound interact handler, also attached to the
+	// context of the active scene.
+	interactHandler *Handler
+
+	// Templates holds the loaded actor templates, by name.
+	Templates map[string]*ActorTemplate
+
 	// Actors holds the loaded actors for the game. They can be used across
 	// scenes so it's not a good idea to tie them tightly to scenes. Scenes will
 	// hold a list of actors that are visible or running on them.
 	Actors map[string]*Actor
 
+	// Listeners are the event listeners, by event name.
+	Listeners map[string][]*Listener
+
 	// Control is the handlers that are loaded into the engine. Soon we should have global
 	// control handlers, and scene independent handlers.
 	Control *Controller
@@ -56,6 +85,9 @@ type Engine struct {
 	// Dt is used to calculate change in game cycle time, used for managing
 	// game timing and motion.
 	Dt float64
+
+	// Time is the total game time that has passed, in seconds.
+	Time float64
 }
 
 // Viewable will be useful at some point.
@@ -105,7 +137,11 @@ func (e *Engine) Initialize(file string) {
 	// Initialize empty system maps.
 	e.Scenes = make(map[string]*Scene)
 	e.Actors = make(map[string]*Actor)
+	e.Templates = make(map[string]*ActorTemplate)
+	e.Interactions = make(map[string]func(target *Interactable, by *Actor))
 	e.ScriptActions = make(map[string]*ScriptAction)
+	e.Variables = make(map[string]string)
+	e.Listeners = make(map[string][]*Listener)
 
 	// Now we can setup our core action library.
 	// TODO: This is too specific, should break it out of basic initialization.
@@ -115,7 +151,7 @@ func (e *Engine) Initialize(file string) {
 // RunScriptAction will run the specified script action.
 func (e *Engine) RunScriptAction(action *Action) interface{} {
 	if a, ok := e.ScriptActions[action.Action]; ok {
-		return a.Runner(action.Args)
+		return a.Runner(e.expandVariables(action.Args))
 	}
 	return nil
 }
@@ -150,7 +186,7 @@ func (e *Engine) NewScene(id string, bgcolor string) error {
 	}
 
 	// Initialize our scene
-	newScene := &Scene{Basespeed: e.Config.Default.Scene.Basespeed, Engine: e}
+	newScene := &Scene{Basespeed: e.Config.Default.Scene.Basespeed, Skin: e.Config.Default.Scene.Skin, SolidLayer: e.Config.Default.Scene.SolidLayer, Engine: e}
 
 	// Setup the rest of our scene collections.
 	newScene.Views = make(map[string]*View)
@@ -175,31 +211,89 @@ func (e *Engine) GetScene(id string) *Scene {
 	return e.Scenes[id]
 }
 
-// ActivateScene will set the currently running scene.
+// findScene will find a scene, for script actions working on a scene_id
+// argument.
+func (e *Engine) findScene(scene string) (*Scene, error) {
+	s, ok := e.Scenes[scene]
+	if !ok {
+		return nil, errors.New("findscene: scene " + scene + " not found")
+	}
+	return s, nil
+}
+
+// sceneActor will find an actor on a scene, for script actions working on
+// scene_id actor_id arguments.
+func (e *Engine) sceneActor(scene string, actor string) (*Actor, error) {
+	s, err := e.findScene(scene)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := s.Actors[actor]
+	if !ok {
+		return nil, errors.New("sceneactor: actor " + actor + " not found on " + scene)
+	}
+	return a, nil
+}
+
+// ActivateScene will set the currently running scene. The input context of
+// the previous scene is removed, and the new scene context goes just below
+// "system", so system handlers such as message boxes still come first. A newly
+// activated scene announces its music and runs its on enter script.
 func (e *Engine) ActivateScene(scene string) {
+	if e.ActiveScene != nil && e.ActiveScene.Context != "" {
+		e.Control.RemoveContext(e.ActiveScene.Context)
+	}
+
+	previous := e.ActiveScene
 	e.ActiveScene = e.Scenes[scene]
+
+	if e.ActiveScene != nil && e.ActiveScene.Context != "" {
+		e.Control.InsertContext(e.ActiveScene.Context, false, "system")
+		e.attachInteract(e.ActiveScene.Context)
+	}
+
+	if e.ActiveScene != nil && e.ActiveScene != previous {
+		e.ActiveScene.enter(previous)
+	}
 }
 
 // NewActor creates a new actor and returns it
 // TODO: Allow for non image actors.
 func (e *Engine) NewActor(filename string, position pixel.Vec) *Actor {
-	newActor := &Actor{Visible: false, Speed: e.Config.Default.Actor.Speed, Collision: true, Position: position}
-	newActor.Src, err = LoadImage(e.Config.System.Directory.Characters + "/" + filename)
-
+	newActor, err := e.newActor(filename, position)
 	if err != nil {
 		fmt.Printf("Error loading image: %s", err.Error())
 		os.Exit(2)
 	}
 
+	return newActor
+}
+
+// newActor will create a new actor, returning an error rather than exiting
+// when the image can't be loaded.
+func (e *Engine) newActor(filename string, position pixel.Vec) (*Actor, error) {
+	newActor := &Actor{Visible: false, Speed: e.Config.Default.Actor.Speed, Reach: e.Config.Default.Actor.Reach, Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Position: position}
+	newActor.Tint = color.RGBA{255, 255, 255, 255}
+	newActor.Properties = make(Properties)
+	newActor.Tags = make([]string, 0)
+
+	src, err := LoadImage(e.Config.System.Directory.Characters + "/" + filename)
+	if err != nil {
+		return nil, err
+	}
+	newActor.Src = src
+
 	// Create our sprite.
 	newActor.Render()
 
-	return newActor
+	return newActor, nil
 }
 
-// AddActor will add an actor to the system.
+// AddActor will add an actor to the system, emitting a "spawn" event.
 func (e *Engine) AddActor(id string, actor *Actor) {
+	actor.ID = id
 	e.Actors[id] = actor
+	e.Emit(&Event{Name: "spawn", Actor: actor})
 }
 
 // Run will run our main game processes
@@ -217,9 +311,29 @@ func (e *Engine) Run() {
 			e.Logic()
 		}
 
+		// Let actor behaviours decide where to go.
+		scene.ProcessActorBehaviours()
+
 		// Process automatic movements via destinations.
 		scene.ProcessActorDestinations()
 
+		// Move along any running animations.
+		scene.ProcessActorAnimations()
+
+		// Move along any running tweens.
+		e.ProcessTweens()
+
+		// Let everyone know who stepped in or out of a trigger.
+		scene.ProcessTriggers()
+
+		// Send the player through any warp they stepped on.
+		scene.ProcessWarps()
+		e.ProcessTransition()
+		scene = e.ActiveScene
+
+		// Let the player know when they can interact.
+		scene.ProcessInteractPrompt()
+
 		// Time to spit out the scene.
 		scene.Draw()
 
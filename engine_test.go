@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/stretchr/testify/assert"
 )
@@ -62,6 +63,55 @@ func TestRunScriptAction(t *testing.T) {
 	assert.Nil(t, badscript)
 }
 
+// runAction will run a script action on the test engine, returning any error.
+func runAction(action string, args ...interface{}) error {
+	err, _ := testEngine.RunScriptAction(&Action{Action: action, Args: args}).(error)
+	return err
+}
+
+// actionScene will add a bare scene holding the actors to the test engine,
+// for trying out script actions. The scene should be deleted when done.
+func actionScene(id string, actors ...*Actor) *Scene {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	for _, a := range actors {
+		scene.Actors[a.ID] = a
+	}
+	testEngine.Scenes[id] = scene
+	return scene
+}
+
+func TestActorActionLookups(t *testing.T) {
+	hero := newTestActor("hero", pixel.ZV)
+	actionScene("lookups", hero)
+	defer delete(testEngine.Scenes, "lookups")
+
+	// Unknown scenes and actors are errors rather than panics.
+	actions := map[string][]interface{}{
+		"ActorAnimations":  {"lizard.xml"},
+		"ActorAnimation":   {"walk_down"},
+		"ActorFacing":      {"90"},
+		"ActorCollision":   {"1", "1", "false"},
+		"PathActor":        {"10", "10", "false", "false"},
+		"ActorBehaviour":   {"none"},
+		"ActorProperty":    {"hp", "int", "5"},
+		"ActorTag":         {"npc"},
+		"ActorUntag":       {"npc"},
+		"SceneRemoveActor": {},
+	}
+	for action, rest := range actions {
+		assert.Error(t, runAction(action, append([]interface{}{"lookups", "nobody"}, rest...)...), action+" should report unknown actors.")
+		assert.Error(t, runAction(action, append([]interface{}{"nowhere", "hero"}, rest...)...), action+" should report unknown scenes.")
+	}
+	assert.Error(t, runAction("SceneMovement", "nowhere", "grid"), "SceneMovement should report unknown scenes.")
+	assert.Error(t, runAction("SpawnActor", "nowhere", "guard1", "guard", "0", "0"), "SpawnActor should report unknown scenes.")
+
+	// Known actors still work.
+	assert.NoError(t, runAction("ActorTag", "lookups", "hero", "npc"))
+	assert.True(t, hero.HasTag("npc"))
+	assert.NoError(t, runAction("SceneRemoveActor", "lookups", "hero"))
+	assert.Empty(t, testEngine.Scenes["lookups"].Actors)
+}
+
 func TestRunScriptFile(t *testing.T) {
 
 	assert.Equal(t, 3, len(testEngine.Scenes), "We should have 3 scenes loaded.")
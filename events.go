@@ -0,0 +1,54 @@
+package gamesys
+
+// Event is something that happened in the game, passed along to anything
+// listening for it.
+type Event struct {
+	// Name is the kind of event, such as "collision".
+	Name string
+
+	// Actor is the actor the event happened to, if any.
+	Actor *Actor
+
+	// Other is the other actor involved, if any.
+	Other *Actor
+
+	// Data holds any extra event details.
+	Data map[string]interface{}
+}
+
+// Listener will run its action when an event it listens for is emitted.
+type Listener struct {
+	// ID will be a string we can use to identify a listener when we need
+	// to remove it.
+	ID string
+
+	// The action to perform
+	Action func(*Event)
+}
+
+// Listen will add a listener for the named event.
+func (e *Engine) Listen(name string, id string, action func(*Event)) {
+	listeners, ok := e.Listeners[name]
+	if !ok {
+		listeners = make([]*Listener, 0)
+	}
+	e.Listeners[name] = append(listeners, &Listener{ID: id, Action: action})
+}
+
+// Unlisten will remove a listener from the named event.
+func (e *Engine) Unlisten(name string, id string) {
+	newListeners := make([]*Listener, 0)
+	for _, l := range e.Listeners[name] {
+		if l.ID != id {
+			newListeners = append(newListeners, l)
+		}
+	}
+	e.Listeners[name] = newListeners
+}
+
+// Emit will pass the event along to everything listening for it.
+func (e *Engine) Emit(event *Event) {
+	for _, l := range e.Listeners[event.Name] {
+		l.Action(event)
+	}
+}
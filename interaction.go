@@ -0,0 +1,208 @@
+package gamesys
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// Interactable is something an actor can interact with, either another
+// actor or a map object. What happens is decided by, in order:
+//
+//	a Go handler added with Engine.OnInteract
+//	the "script" property, a script file to run
+//	the "dialog" property, a message to show
+//
+// The "prompt" property replaces the prompt shown when in reach.
+type Interactable struct {
+	// Name is the actor id or the map object name.
+	Name string
+
+	// Area is where the interactable is on the map.
+	Area pixel.Rect
+
+	// Properties are the custom properties, holding the handlers.
+	Properties Properties
+
+	// Actor is the actor being interacted with, nil for map objects.
+	Actor *Actor
+}
+
+// OnInteract will set the Go handler for the actor id or map object name,
+// replacing any script or dialog.
+func (e *Engine) OnInteract(name string, handler func(target *Interactable, by *Actor)) {
+	e.Interactions[name] = handler
+}
+
+// BindInteract will make the button interact with whatever is in front of
+// the focused actor, on any scene. The handler goes in the "app" context and
+// the context of the active scene, so scenes with their own input still
+// interact. The prompt is shown while something is in reach.
+func (e *Engine) BindInteract(button pixelgl.Button, prompt string) {
+	e.InteractPrompt = prompt
+	e.interactHandler = &Handler{ID: "interact", Button: button, Sensitive: true, Action: func() {
+		if actor := e.ActiveScene.FocusedActor(); actor != nil {
+			e.ActiveScene.Interact(actor)
+		}
+	}}
+
+	e.Control.RemoveHandler("app", "interact")
+	e.Control.AttachHandler("app", e.interactHandler)
+	if e.ActiveScene != nil {
+		e.attachInteract(e.ActiveScene.Context)
+	}
+}
+
+// attachInteract will add the bound interact handler to the input context,
+// replacing any there already.
+func (e *Engine) attachInteract(context string) {
+	if e.interactHandler == nil || context == "" {
+		return
+	}
+	e.Control.RemoveHandler(context, "interact")
+	e.Control.AttachHandler(context, e.interactHandler)
+}
+
+// interactive will indicate if there is anything to do with the target.
+func (e *Engine) interactive(name string, properties Properties) bool {
+	if _, ok := e.Interactions[name]; ok {
+		return true
+	}
+	return properties.Has("script") || properties.Has("dialog")
+}
+
+// FocusedActor will return the actor focused on by the first view that has
+// one, being the player in most games.
+func (s *Scene) FocusedActor() *Actor {
+	for _, id := range s.ViewOrder {
+		if v, ok := s.Views[id]; ok && v.Focus != nil {
+			return v.Focus
+		}
+	}
+	return nil
+}
+
+// FindInteractable will return the closest thing the actor can interact
+// with, within reach in front of it. Nil is returned when there is nothing.
+func (s *Scene) FindInteractable(actor *Actor) *Interactable {
+	front := actor.InFront(actor.Reach)
+
+	// Gather everything in front of us.
+	found := make([]*Interactable, 0)
+	for _, a := range s.nearbyActors(front, func(a *Actor) bool {
+		return a.Root() != actor.Root() && a.Clip.Intersects(front) && s.Engine.interactive(a.ID, a.Properties)
+	}) {
+		found = append(found, &Interactable{Name: a.ID, Area: a.Clip, Properties: a.Properties, Actor: a})
+	}
+	if s.MapData != nil {
+		for _, i := range s.MapData.Interactables {
+			// Point objects have no size, so need to be inside.
+			inside := front.Contains(i.Area.Min)
+			if i.Area.Area() > 0 {
+				inside = front.Intersects(i.Area)
+			}
+			if inside {
+				found = append(found, i)
+			}
+		}
+	}
+
+	// The closest wins.
+	var closest *Interactable
+	for _, i := range found {
+		if closest == nil || actor.Position.To(i.Area.Center()).Len() < actor.Position.To(closest.Area.Center()).Len() {
+			closest = i
+		}
+	}
+
+	return closest
+}
+
+// Interact will have the actor interact with whatever is in front of it,
+// returning false if there was nothing. An "interact" event is emitted, and
+// scripts have the target and interactor variables set to who was involved.
+func (s *Scene) Interact(actor *Actor) bool {
+	target := s.FindInteractable(actor)
+	if target == nil {
+		return false
+	}
+
+	e := s.Engine
+	e.Emit(&Event{Name: "interact", Actor: target.Actor, Other: actor, Data: map[string]interface{}{"target": target}})
+
+	if handler, ok := e.Interactions[target.Name]; ok {
+		handler(target, actor)
+	} else if script := target.Properties.String("script"); script != "" {
+		e.SetVariable("target", target.Name)
+		e.SetVariable("interactor", actor.ID)
+		e.RunScriptFile(script)
+	} else if dialog := target.Properties.String("dialog"); dialog != "" {
+		e.DisplayMessageBox(dialog)
+	}
+
+	return true
+}
+
+// ProcessInteractPrompt will show the interact prompt while the focused
+// actor has something in reach, and hide it otherwise. Nothing is shown
+// while a messagebox is up.
+func (s *Scene) ProcessInteractPrompt() {
+	e := s.Engine
+	if e.InteractPrompt == "" {
+		return
+	}
+
+	var target *Interactable
+	_, messagebox := s.Views["messagebox"]
+	if actor := s.FocusedActor(); actor != nil && !messagebox {
+		target = s.FindInteractable(actor)
+	}
+
+	_, showing := s.Views["prompt"]
+	switch {
+	case target == nil && showing:
+		s.RemoveView("prompt")
+	case target != nil && !showing:
+		s.showPrompt()
+	}
+
+	// Keep our message up to date with whatever we are facing.
+	if target != nil {
+		s.Views["prompt"].DesignView = s.designPrompt(target)
+	}
+}
+
+// showPrompt will create the prompt view, along the bottom of the
+// messagebox area.
+func (s *Scene) showPrompt() {
+	msgConfig := s.Engine.Config.Default.MessageBox
+	height := s.Engine.Font.LineHeight() + 4
+
+	s.NewView("prompt", pixel.V(msgConfig.X, msgConfig.Y-msgConfig.Height/2-height/2), pixel.R(0, 0, msgConfig.Width, height), msgConfig.BGColor)
+	s.Views["prompt"].Show()
+}
+
+// designPrompt will draw the prompt for the target.
+func (s *Scene) designPrompt(target *Interactable) func() {
+	msgConfig := s.Engine.Config.Default.MessageBox
+	view := s.Views["prompt"]
+
+	prompt := s.Engine.InteractPrompt
+	if target.Properties.Has("prompt") {
+		prompt = target.Properties.String("prompt")
+	}
+
+	return func() {
+		view.Rendered.Clear(colornames.Map[msgConfig.BGColor])
+		promptTxt := text.New(pixel.ZV, s.Engine.Font)
+		promptTxt.Color = colornames.Map[msgConfig.Color]
+		fmt.Fprint(promptTxt, prompt)
+
+		view.Rendered.SetMatrix(pixel.IM.Moved(pixel.V(2, 4)))
+		promptTxt.Draw(view.Rendered, pixel.IM)
+		view.Rendered.SetMatrix(pixel.IM)
+	}
+}
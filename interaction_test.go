@@ -0,0 +1,110 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteract(t *testing.T) {
+	e := testEngine
+	assert.Len(t, e.Scenes["test1"].MapData.Interactables, 1, "Our sign should be found on the map.")
+
+	scene := &Scene{Engine: e, Actors: make(map[string]*Actor), Views: make(map[string]*View), MapData: e.Scenes["test1"].MapData}
+	hero := newTestActor("hero", pixel.V(240, 48))
+	hero.Reach = 16
+	scene.Actors["hero"] = hero
+
+	// Our sign is off to the right.
+	hero.Face(180)
+	assert.Nil(t, scene.FindInteractable(hero), "We should need to face things.")
+	hero.Face(0)
+	sign := scene.FindInteractable(hero)
+	if assert.NotNil(t, sign) {
+		assert.Equal(t, "Sign", sign.Name)
+	}
+
+	// Dialogs come up in a messagebox.
+	assert.True(t, scene.Interact(hero))
+	_, err := e.ActiveScene.GetView("messagebox")
+	assert.NoError(t, err, "Our dialog should be showing.")
+	e.ActiveScene.RemoveView("messagebox")
+	e.Control.RemoveHandler("system", "messagebox")
+
+	// A closer actor with a handler wins.
+	npc := newTestActor("npc", pixel.V(250, 48))
+	scene.Actors["npc"] = npc
+	assert.Equal(t, "Sign", scene.FindInteractable(hero).Name, "Actors without handlers are ignored.")
+	talked := false
+	e.OnInteract("npc", func(target *Interactable, by *Actor) {
+		talked = target.Actor == npc && by == hero
+	})
+	defer delete(e.Interactions, "npc")
+	assert.True(t, scene.Interact(hero))
+	assert.True(t, talked, "Our handler should be run.")
+
+	// Move away and there is nothing to do.
+	hero.MoveTo(pixel.V(100, 48))
+	assert.False(t, scene.Interact(hero))
+}
+
+func TestInteractPrompt(t *testing.T) {
+	e := testEngine
+	e.InteractPrompt = "Press Enter"
+	defer func() { e.InteractPrompt = "" }()
+
+	scene := &Scene{Engine: e, Actors: make(map[string]*Actor), Views: make(map[string]*View), MapData: e.Scenes["test1"].MapData}
+	hero := newTestActor("hero", pixel.V(240, 48))
+	hero.Reach = 16
+	scene.Actors["hero"] = hero
+	scene.NewView("main", pixel.ZV, pixel.R(0, 0, 100, 100), "black")
+	scene.Views["main"].FocusOn(hero)
+
+	scene.ProcessInteractPrompt()
+	assert.Contains(t, scene.Views, "prompt", "Our prompt should show in reach.")
+
+	hero.MoveTo(pixel.V(100, 48))
+	scene.ProcessInteractPrompt()
+	assert.NotContains(t, scene.Views, "prompt", "Our prompt should go away.")
+}
+
+func TestActorReachAction(t *testing.T) {
+	hero := newTestActor("hero", pixel.ZV)
+	actionScene("reaching", hero)
+	defer delete(testEngine.Scenes, "reaching")
+
+	assert.NoError(t, runAction("ActorReach", "reaching", "hero", "24"))
+	assert.Equal(t, 24.0, hero.Reach, "Our reach should be set.")
+	assert.Error(t, runAction("ActorReach", "reaching", "nobody", "24"), "Unknown actors should be an error.")
+}
+
+func TestBindInteractSceneContext(t *testing.T) {
+	c, buttons := newFakeController()
+	e := c.Engine
+	e.Interactions = make(map[string]func(target *Interactable, by *Actor))
+
+	// A scene with its own input, and someone to talk to.
+	hero := newTestActor("hero", pixel.V(100, 100))
+	hero.Reach = 16
+	npc := newTestActor("npc", pixel.V(110, 100))
+	scene := &Scene{Engine: e, Context: "town", Actors: map[string]*Actor{"hero": hero, "npc": npc}, Views: map[string]*View{"main": {Focus: hero}}, ViewOrder: []string{"main"}}
+	e.Scenes["town"] = scene
+	c.AddHandler("town", "menu", pixelgl.KeyEscape, true, func() {})
+	talked := 0
+	e.OnInteract("npc", func(target *Interactable, by *Actor) { talked++ })
+
+	// Binding before and after the scene is active both work.
+	e.BindInteract(pixelgl.KeyEnter, "")
+	e.ActivateScene("town")
+	buttons.set(pixelgl.KeyEnter)
+	c.Run()
+	assert.Equal(t, 1, talked, "Interact should fire with a scene context present.")
+
+	e.BindInteract(pixelgl.KeySpace, "")
+	buttons.set(pixelgl.KeySpace)
+	c.Run()
+	assert.Equal(t, 2, talked, "Rebinding should reach the active scene.")
+	assert.Len(t, c.Handlers["town"], 2, "We should not duplicate the handler.")
+}
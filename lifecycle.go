@@ -0,0 +1,135 @@
+package gamesys
+
+import (
+	"errors"
+
+	"github.com/faiface/pixel"
+)
+
+// RemoveActor will take the actor off the scene, leaving it on the engine to
+// be used again. It is dropped from every view, and views focused on it lose
+// their focus. A "leave" event is emitted with the scene in the data.
+func (s *Scene) RemoveActor(actor string) {
+	a, ok := s.Actors[actor]
+	if !ok {
+		return
+	}
+	delete(s.Actors, actor)
+	s.unindexActor(a)
+
+	for _, v := range s.Views {
+		visible := make([]string, 0)
+		for _, id := range v.VisibleActors {
+			if id != actor {
+				visible = append(visible, id)
+			}
+		}
+		v.VisibleActors = visible
+
+		if v.Focus == a {
+			v.Focus = nil
+		}
+	}
+
+	// Nobody is touching us any more.
+	for _, other := range s.Actors {
+		delete(other.contacts, a)
+	}
+	a.contacts = nil
+	a.triggers = nil
+
+	s.Engine.Emit(&Event{Name: "leave", Actor: a, Data: map[string]interface{}{"scene": s}})
+}
+
+// RemoveActor will despawn the actor, removing it from every scene and from
+// the engine along with any running tweens. Children are removed with it.
+// A "despawn" event is emitted once it is gone.
+func (e *Engine) RemoveActor(actor string) error {
+	a, ok := e.Actors[actor]
+	if !ok {
+		return errors.New("removeactor: actor " + actor + " not found")
+	}
+
+	// Take our children with us, copied as they detach as they go.
+	children := append([]*Actor{}, a.Children...)
+	for _, c := range children {
+		if _, ok := e.Actors[c.ID]; ok {
+			err = e.RemoveActor(c.ID)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.Detach()
+		}
+	}
+	a.Detach()
+
+	for _, s := range e.Scenes {
+		s.RemoveActor(actor)
+	}
+
+	tweens := make([]*Tween, 0)
+	for _, t := range e.Tweens {
+		if t.Actor != a {
+			tweens = append(tweens, t)
+		}
+	}
+	e.Tweens = tweens
+
+	delete(e.Actors, actor)
+	e.Emit(&Event{Name: "despawn", Actor: a})
+
+	return nil
+}
+
+// TransferActor will move the actor from one scene to another, keeping all of
+// its state. Children go along with it. The actor is placed at the position
+// and made visible on the given views of the new scene.
+func (e *Engine) TransferActor(actor string, from string, to string, position pixel.Vec, views ...string) error {
+	a, ok := e.Actors[actor]
+	if !ok {
+		return errors.New("transferactor: actor " + actor + " not found")
+	}
+	fromScene, ok := e.Scenes[from]
+	if !ok {
+		return errors.New("transferactor: scene " + from + " not found")
+	}
+	toScene, ok := e.Scenes[to]
+	if !ok {
+		return errors.New("transferactor: scene " + to + " not found")
+	}
+
+	for _, member := range a.family() {
+		if _, ok := e.Actors[member.ID]; !ok {
+			continue
+		}
+
+		fromScene.RemoveActor(member.ID)
+		err = toScene.UseActor(member.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range views {
+			view, ok := toScene.Views[v]
+			if !ok {
+				return errors.New("transferactor: view " + v + " not found")
+			}
+			view.VisibleActors = append(view.VisibleActors, member.ID)
+		}
+	}
+
+	a.MoveTo(position)
+
+	return nil
+}
+
+// family will return the actor along with all of its children, and their
+// children.
+func (a *Actor) family() []*Actor {
+	family := []*Actor{a}
+	for _, c := range a.Children {
+		family = append(family, c.family()...)
+	}
+	return family
+}
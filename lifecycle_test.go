@@ -0,0 +1,75 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+// newLifecycleEngine will make an engine with two scenes sharing a hero and
+// a sword.
+func newLifecycleEngine() (*Engine, *Actor, *Actor) {
+	e := &Engine{Actors: make(map[string]*Actor), Scenes: make(map[string]*Scene), Listeners: make(map[string][]*Listener)}
+	for _, id := range []string{"town", "cave"} {
+		scene := &Scene{Engine: e, Actors: make(map[string]*Actor), Views: make(map[string]*View)}
+		scene.Views["main"] = &View{Scene: scene}
+		e.Scenes[id] = scene
+	}
+
+	hero := newTestActor("hero", pixel.V(100, 100))
+	sword := newTestActor("sword", pixel.ZV)
+	e.AddActor("hero", hero)
+	e.AddActor("sword", sword)
+	sword.Attach(hero, pixel.V(10, 0))
+
+	town := e.Scenes["town"]
+	town.UseActor("hero")
+	town.UseActor("sword")
+	town.Views["main"].VisibleActors = []string{"hero", "sword"}
+	town.Views["main"].FocusOn(hero)
+
+	return e, hero, sword
+}
+
+func TestUseActor(t *testing.T) {
+	e, _, _ := newLifecycleEngine()
+	assert.Error(t, e.Scenes["town"].UseActor("nobody"), "Unknown actors should be rejected.")
+	assert.NotContains(t, e.Scenes["town"].Actors, "nobody", "Nothing should be stored.")
+}
+
+func TestRemoveActor(t *testing.T) {
+	e, hero, _ := newLifecycleEngine()
+
+	events := make([]string, 0)
+	for _, name := range []string{"leave", "despawn"} {
+		e.Listen(name, "test", func(ev *Event) { events = append(events, ev.Name+":"+ev.Actor.ID) })
+	}
+
+	assert.NoError(t, e.RemoveActor("hero"))
+	town := e.Scenes["town"]
+	assert.Empty(t, e.Actors, "Children should be removed too.")
+	assert.Empty(t, town.Actors)
+	assert.Empty(t, town.Views["main"].VisibleActors)
+	assert.Nil(t, town.Views["main"].Focus, "Views should lose their focus.")
+	assert.Empty(t, hero.Children)
+	assert.Equal(t, []string{"leave:sword", "despawn:sword", "leave:hero", "despawn:hero"}, events)
+
+	assert.Error(t, e.RemoveActor("hero"), "We can't remove twice.")
+}
+
+func TestTransferActor(t *testing.T) {
+	e, hero, sword := newLifecycleEngine()
+	hero.SetProperty("gold", 12)
+
+	assert.Error(t, e.TransferActor("hero", "town", "moon", pixel.ZV), "Unknown scenes should be rejected.")
+	assert.NoError(t, e.TransferActor("hero", "town", "cave", pixel.V(50, 50), "main"))
+
+	town, cave := e.Scenes["town"], e.Scenes["cave"]
+	assert.Empty(t, town.Actors)
+	assert.Equal(t, hero, cave.Actors["hero"])
+	assert.Equal(t, sword, cave.Actors["sword"], "Children should come along.")
+	assert.Equal(t, []string{"hero", "sword"}, cave.Views["main"].VisibleActors)
+	assert.Equal(t, pixel.V(60, 50), sword.Position, "Children should follow us to our new spot.")
+	assert.Equal(t, 12, hero.Properties["gold"], "We should keep our state.")
+}
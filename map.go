@@ -2,10 +2,10 @@ package gamesys
 
 import (
 	"errors"
+	"image"
 
 	"github.com/faiface/pixel"
 	"github.com/lafriks/go-tiled"
-	"github.com/lafriks/go-tiled/render"
 )
 
 // Map will contain our map with some easy to use stuff, like for rendering
@@ -16,12 +16,64 @@ type Map struct {
 	// Size will be the size of our map, pulled from our map data
 	Size pixel.Vec
 
-	// The rendered full map. We set this up as an array so that in future
-	// we can process assorted layers independently if needed.
+	// Grid is how the map tiles are laid out, for whichever orientation the
+	// map has.
+	Grid MapGrid
+
+	// Origin is the column and row of the tile Tiled places at 0,0. It is
+	// only ever set for infinite maps reaching left of or above it, as
+	// everything is moved so the top left tile is at column 0, row 0.
+	Origin image.Point
+
+	// Layers hold the runtime settings for each tile layer, in map order.
+	Layers []*MapLayer
+
+	// Tiles draws our tile layers.
+	Tiles *TileRenderer
+
+	// Img holds a picture of each tile layer covering the whole map, once
+	// the map has been pre-rendered. Views show these instead of drawing
+	// tiles when they are there.
 	Img []*pixel.PictureData
 
-	// Our collision information, a collection of map objects.
-	Collision []*pixel.Rect
+	// Our collision information, the shapes of map objects and tiles.
+	Collision []Shape
+
+	// Interactables are the map objects that can be interacted with.
+	Interactables []*Interactable
+
+	// Properties are the custom map properties, including the scene
+	// settings.
+	Properties Properties
+
+	// Warps are the map objects that send actors somewhere else.
+	Warps []*Warp
+
+	// Triggers are the map areas that react to actors entering them.
+	Triggers []*TriggerArea
+
+	// ObjectGroups are all of the object groups on the map, including those
+	// nested in group layers.
+	ObjectGroups []*tiled.ObjectGroup
+
+	// chunks are the tiles of each layer of an infinite map, which aren't
+	// held by the layers themselves.
+	chunks []*tileChunks
+
+	// imgAnimated are the animated tiles drawn into Img, with the frames
+	// they were drawn at, and imgVersions count the changes to each
+	// picture so views know to show them again.
+	imgAnimated []*animatedTile
+	imgFrames   []uint32
+	imgVersions []int
+
+	// roles are the roles of each map object.
+	roles map[*tiled.Object]string
+
+	// collisionGrid is the spatial index of our collision shapes, holding
+	// the first indexed shapes.
+	collisionGrid *SpatialGrid
+	indexed       int
 }
 
 // NewMap will load and initialize a map from a mapfile. If we need
@@ -31,10 +83,12 @@ type Map struct {
 func NewMap(mapfile string) (*Map, error) {
 	// Initialize empty map information.
 	newMap := &Map{}
-	newMap.Img = make([]*pixel.PictureData, 0)
 
 	// Load up the source map file.
-	newMap.Src, err = tiled.LoadFromFile(mapfile)
+	var header tmxHeader
+	newMap.Src, header, err = loadTiledMap(mapfile)
+	newMap.Origin = header.Origin
+	newMap.chunks = header.Chunks
 
 	// Unable to proceed if we don't load the file properly.
 	if err != nil {
@@ -42,35 +96,145 @@ func NewMap(mapfile string) (*Map, error) {
 	}
 
 	// Grab some of our map information
-	newMap.Size = pixel.V(float64(newMap.Src.Width*newMap.Src.TileWidth), float64(newMap.Src.Height*newMap.Src.TileHeight))
+	newMap.Properties = make(Properties)
+	if newMap.Src.Properties != nil {
+		newMap.Properties = PropertiesFromTiled(*newMap.Src.Properties)
+	}
+	newMap.Grid = MapGrid{
+		Orientation: newMap.Src.Orientation,
+		Columns:     newMap.Src.Width,
+		Rows:        newMap.Src.Height,
+		TileSize:    pixel.V(float64(newMap.Src.TileWidth), float64(newMap.Src.TileHeight)),
+		StaggerX:    header.StaggerAxis == "x",
+		StaggerEven: header.StaggerIndex == "even",
+		HexSide:     float64(newMap.Src.HexSideLength),
+	}
+	newMap.Size = newMap.Grid.Size()
 
-	// This creates our map renderer.
-	renderer, err := render.NewRenderer(newMap.Src)
-	if err != nil {
+	// We know how to lay out the Tiled orientations, and nothing else.
+	switch newMap.Src.Orientation {
+	case OrientationOrthogonal, OrientationIsometric, OrientationStaggered, OrientationHexagonal:
+	default:
 		return newMap, errors.New("newmap: map unsupported")
 	}
 
-	// Render all visible layers.
-	err = renderer.RenderVisibleLayers()
-	if err != nil {
-		return newMap, errors.New("newmap: maplayer unsupported")
+	// Tiles are drawn as they come into view, so we only keep the settings
+	// of each layer. Hidden ones are kept too so they can be shown later.
+	for _, layer := range newMap.Src.Layers {
+		newMap.Layers = append(newMap.Layers, &MapLayer{Name: layer.Name, Above: layer.Properties.GetBool("above"), Visible: layer.Visible, Opacity: float64(layer.Opacity)})
+	}
+	newMap.Tiles = NewTileRenderer(newMap)
+
+	// Small maps can be drawn up front instead, with the "prerender" map
+	// property.
+	if newMap.Properties.Bool("prerender") {
+		err = newMap.PreRender()
+		if err != nil {
+			return newMap, err
+		}
 	}
 
-	// Convert into pixel's image/sprite format.
-	newMap.Img = append(newMap.Img, pixel.PictureDataFromImage(renderer.Result))
+	// Find all of our objects, and what they are for.
+	newMap.loadObjects()
 
-	// Setup collision objects, the first object layer will hold that, eventually configurable
-	for _, obj := range newMap.Src.ObjectGroups[0].Objects {
-		if obj.Type == "Collision" {
-			// We need to build the rects properly. The tiled
-			// map starts from the top down, have to reverse y.
-			newY := newMap.Size.Y - obj.Y - obj.Height
-			newCollision := pixel.R(obj.X+2, newY+2, obj.X+obj.Width-2, newY+obj.Height-2)
+	// Setup collision objects.
+	for _, obj := range newMap.Objects(RoleCollision) {
+		newMap.addCollision(newMap.ObjectShape(obj))
+	}
 
-			newMap.Collision = append(newMap.Collision, &newCollision)
-		}
+	// Tiles can carry their own collision too.
+	newMap.loadTileCollision()
+	newMap.indexCollision()
+
+	// Anything with a dialog or script can be interacted with, while spawned
+	// actors keep theirs as properties.
+	for _, obj := range newMap.filterObjects(func(obj *tiled.Object) bool {
+		return newMap.ObjectRole(obj) != RoleSpawn && (obj.Properties.GetString("dialog") != "" || obj.Properties.GetString("script") != "")
+	}) {
+		newMap.Interactables = append(newMap.Interactables, &Interactable{Name: obj.Name, Area: newMap.ObjectRect(obj), Properties: PropertiesFromTiled(obj.Properties)})
+	}
+
+	// Warps send whoever steps on them elsewhere.
+	for _, obj := range newMap.Objects(RoleWarp) {
+		newMap.Warps = append(newMap.Warps, NewWarp(obj, newMap.ObjectRect(obj), mapfile))
+	}
+
+	// Triggers let us know who steps in and out of them.
+	for _, obj := range newMap.Objects(RoleTrigger) {
+		newMap.Triggers = append(newMap.Triggers, NewTriggerArea(obj, newMap.ObjectRect(obj)))
 	}
 
 	// Return our loaded map, along with nil error response.
 	return newMap, nil
 }
+
+// MapLayer holds the runtime settings of a tile layer.
+type MapLayer struct {
+	// Name is the Tiled layer name.
+	Name string
+
+	// Above layers are drawn over actors, like roofs and treetops. This is
+	// set by the Tiled "above" layer property.
+	Above bool
+
+	// Visible indicates if the layer is drawn.
+	Visible bool
+
+	// Opacity is how opaque the layer is, from 0 to 1.
+	Opacity float64
+}
+
+// Layer will return the tile layer with the given name.
+func (m *Map) Layer(name string) (*MapLayer, error) {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return nil, errors.New("layer: layer " + name + " not found")
+}
+
+// Tile will return the tile placed on the layer at the column and row,
+// counting from the top left. Empty cells and those off the map give the
+// nil tile.
+func (m *Map) Tile(layer int, column int, row int) *tiled.LayerTile {
+	if column < 0 || row < 0 || column >= m.Src.Width || row >= m.Src.Height {
+		return tiled.NilLayerTile
+	}
+	if m.chunks != nil {
+		return m.chunks[layer].tile(column, row)
+	}
+	return m.Src.Layers[layer].Tiles[row*m.Src.Width+column]
+}
+
+// ObjectRect will return the area of a map object as a map rect. Objects
+// on isometric maps are diamonds, so give the box around them.
+func (m *Map) ObjectRect(obj *tiled.Object) pixel.Rect {
+	return pointBounds([]pixel.Vec{
+		m.ToWorld(pixel.V(obj.X, obj.Y)),
+		m.ToWorld(pixel.V(obj.X+obj.Width, obj.Y)),
+		m.ToWorld(pixel.V(obj.X, obj.Y+obj.Height)),
+		m.ToWorld(pixel.V(obj.X+obj.Width, obj.Y+obj.Height)),
+	})
+}
+
+// ObjectPoints will return the points of a polyline or polygon object as
+// map positions. Other objects give their own position.
+func (m *Map) ObjectPoints(obj *tiled.Object) []pixel.Vec {
+	var points tiled.Points
+	if len(obj.PolyLines) > 0 && obj.PolyLines[0].Points != nil {
+		points = *obj.PolyLines[0].Points
+	} else if len(obj.Polygons) > 0 && obj.Polygons[0].Points != nil {
+		points = *obj.Polygons[0].Points
+	}
+
+	if len(points) == 0 {
+		return []pixel.Vec{m.ToWorld(pixel.V(obj.X, obj.Y))}
+	}
+
+	positions := make([]pixel.Vec, len(points))
+	for i, p := range points {
+		positions[i] = m.ToWorld(pixel.V(obj.X+p.X, obj.Y+p.Y))
+	}
+	return positions
+}
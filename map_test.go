@@ -0,0 +1,158 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapObjectGroups(t *testing.T) {
+	m, err := NewMap("test_assets/maps/groups.tmx")
+	assert.NoError(t, err)
+
+	assert.Len(t, m.ObjectGroups, 3, "Nested object groups should be found.")
+	assert.NotNil(t, m.ObjectGroup("Inner"), "We should find groups by name.")
+	assert.Nil(t, m.ObjectGroup("Nowhere"))
+
+	// Roles come from object types, group properties and group names.
+	assert.Len(t, m.Collision, 2, "Both the wall group and wall object should collide.")
+	assert.Equal(t, pixel.R(0, 96, 128, 128), m.Collision[0].Bounds())
+	assert.Len(t, m.Objects(RoleSpawn), 1)
+	assert.Len(t, m.Objects(RoleRegion), 2, "Nested groups take the role of their parent.")
+	assert.Equal(t, RoleRegion, m.ObjectRole(m.FindObject("Garden")))
+	assert.Len(t, m.ObjectsOfType("Door"), 1)
+	assert.Equal(t, "Door", m.FindObject("Door").Name, "We should find nested objects.")
+
+	// Spawns work from any group.
+	assert.NoError(t, testEngine.NewScene("groupmap", "black"))
+	defer delete(testEngine.Scenes, "groupmap")
+	defer delete(testEngine.Actors, "groupguard")
+	scene := testEngine.GetScene("groupmap")
+	assert.NoError(t, scene.LoadMap("test_assets/maps/groups.tmx"))
+	assert.Contains(t, scene.Actors, "groupguard", "Untyped objects in a spawn group should spawn.")
+
+	// Spawns without anything to show are rejected, rather than crashing.
+	assert.NoError(t, testEngine.NewScene("markermap", "black"))
+	defer delete(testEngine.Scenes, "markermap")
+	assert.Error(t, testEngine.GetScene("markermap").LoadMap("test_assets/maps/infinite.tmx"))
+}
+
+func TestMapNoObjectGroups(t *testing.T) {
+	m, err := NewMap("test_assets/maps/empty.tmx")
+	assert.NoError(t, err)
+	assert.Empty(t, m.ObjectGroups)
+	assert.Empty(t, m.Collision)
+	assert.Nil(t, m.FindObject("anything"))
+
+	assert.NoError(t, testEngine.NewScene("emptymap", "black"))
+	defer delete(testEngine.Scenes, "emptymap")
+	scene := testEngine.GetScene("emptymap")
+	assert.NoError(t, scene.LoadMap("test_assets/maps/empty.tmx"), "Maps without objects should still load.")
+}
+
+func TestParseRole(t *testing.T) {
+	assert.Equal(t, RoleSpawn, ParseRole("Spawns"))
+	assert.Equal(t, RoleCollision, ParseRole("collision"))
+	assert.Equal(t, "", ParseRole("Objects"))
+}
+
+func TestMapLayers(t *testing.T) {
+	m, err := NewMap("test_assets/maps/groups.tmx")
+	assert.NoError(t, err)
+	assert.Len(t, m.Layers, 2, "Each tile layer should be kept.")
+
+	base, err := m.Layer("Base")
+	assert.NoError(t, err)
+	assert.False(t, base.Above)
+	assert.True(t, base.Visible)
+
+	roof, err := m.Layer("Roof")
+	assert.NoError(t, err)
+	assert.True(t, roof.Above, "Layers can be marked to go over actors.")
+	assert.False(t, roof.Visible, "Hidden layers stay hidden.")
+	assert.InDelta(t, 0.5, roof.Opacity, 1e-6)
+
+	_, err = m.Layer("Nowhere")
+	assert.Error(t, err)
+}
+
+func TestTileCollision(t *testing.T) {
+	m, err := NewMap("test_assets/maps/tilecollision.tmx")
+	assert.NoError(t, err)
+
+	// Solid tiles, then tile shapes, including a flipped one.
+	expected := []pixel.Rect{
+		pixel.R(0, 64, 32, 96),
+		pixel.R(32, 32, 40, 48),
+		pixel.R(88, 0, 96, 16),
+	}
+	if assert.Len(t, m.Collision, 3) {
+		for i, c := range m.Collision {
+			assert.Equal(t, expected[i], c.Bounds())
+		}
+	}
+
+	m.SolidLayer("Collision")
+	assert.Len(t, m.Collision, 4, "Every tile on the solid layer should collide.")
+	assert.Equal(t, pixel.R(0, 0, 32, 32), m.Collision[3].Bounds())
+
+	m.SolidLayer("Nowhere")
+	assert.Len(t, m.Collision, 4, "Missing layers add nothing.")
+}
+
+func TestTileCollisionPlacement(t *testing.T) {
+	m, err := NewMap("test_assets/maps/tileshapes.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// A shape along the top of a big tile, moved by the layer offset, then
+	// flipped across the diagonal and across the tile.
+	expected := []pixel.Rect{
+		pixel.R(4, 48, 20, 56),
+		pixel.R(0, 48, 8, 64),
+		pixel.R(48, 56, 64, 64),
+	}
+	if assert.Len(t, m.Collision, 3) {
+		for i, c := range m.Collision {
+			assert.Equal(t, expected[i], c.Bounds())
+		}
+	}
+}
+
+func TestMapTriggers(t *testing.T) {
+	assert.NoError(t, testEngine.NewScene("triggermap", "black"))
+	defer delete(testEngine.Scenes, "triggermap")
+	scene := testEngine.GetScene("triggermap")
+	assert.NoError(t, scene.LoadMap("test_assets/maps/triggers.tmx"))
+
+	// Only objects with the trigger role become triggers.
+	if !assert.Len(t, scene.MapData.Triggers, 1) {
+		return
+	}
+	trap := scene.MapData.Triggers[0]
+	assert.Equal(t, "Trap", trap.Name)
+	assert.Equal(t, pixel.R(64, 0, 96, 64), trap.Area)
+	assert.Equal(t, 3, trap.Properties.Int("damage"))
+
+	walker := newTestActor("walker", pixel.V(20, 16))
+	scene.Actors["walker"] = walker
+
+	events := make([]string, 0)
+	for _, name := range []string{"triggerenter", "triggerexit"} {
+		testEngine.Listen(name, "triggertest", func(e *Event) {
+			assert.Equal(t, trap, e.Data["trigger"])
+			events = append(events, e.Name)
+		})
+		defer testEngine.Unlisten(name, "triggertest")
+	}
+
+	scene.ProcessTriggers()
+	walker.MoveTo(pixel.V(80, 16))
+	scene.ProcessTriggers()
+	scene.ProcessTriggers()
+	walker.MoveTo(pixel.V(20, 16))
+	scene.ProcessTriggers()
+	assert.Equal(t, []string{"triggerenter", "triggerexit"}, events, "Stepping in and out should be reported once each.")
+}
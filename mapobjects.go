@@ -0,0 +1,202 @@
+package gamesys
+
+import (
+	"math"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+const (
+	// RoleCollision objects are areas actors can't walk through.
+	RoleCollision = "collision"
+
+	// RoleSpawn objects create actors when the map is loaded.
+	RoleSpawn = "spawn"
+
+	// RoleTrigger objects are areas that react to actors entering and
+	// leaving them.
+	RoleTrigger = "trigger"
+
+	// RoleWarp objects send actors somewhere else.
+	RoleWarp = "warp"
+
+	// RoleRegion objects mark out named areas, such as patrol routes.
+	RoleRegion = "region"
+)
+
+// ParseRole will return the role a Tiled name declares, ignoring case and
+// a plural, so "Spawns" is RoleSpawn. Names that aren't roles give an empty
+// role.
+func ParseRole(name string) string {
+	name = strings.ToLower(name)
+	for _, role := range []string{RoleCollision, RoleSpawn, RoleTrigger, RoleWarp, RoleRegion} {
+		if name == role || name == role+"s" {
+			return role
+		}
+	}
+	return ""
+}
+
+// groupRole will find the role declared by a group, by its "role" property
+// or its name, falling back to the role of the group holding it.
+func groupRole(name string, properties tiled.Properties, parent string) string {
+	if role := ParseRole(properties.GetString("role")); role != "" {
+		return role
+	}
+	if role := ParseRole(name); role != "" {
+		return role
+	}
+	return parent
+}
+
+// loadObjects will gather every object group on the map, including those
+// nested in group layers, and work out the role of each object. Objects
+// declare their own role by type, or take the role of their group.
+func (m *Map) loadObjects() {
+	m.ObjectGroups = make([]*tiled.ObjectGroup, 0)
+	m.roles = make(map[*tiled.Object]string)
+
+	var walk func(groups []*tiled.ObjectGroup, nested []*tiled.Group, parent string)
+	walk = func(groups []*tiled.ObjectGroup, nested []*tiled.Group, parent string) {
+		for _, g := range groups {
+			m.ObjectGroups = append(m.ObjectGroups, g)
+			role := groupRole(g.Name, g.Properties, parent)
+			for _, obj := range g.Objects {
+				m.roles[obj] = role
+				if objRole := ParseRole(obj.Type); objRole != "" {
+					m.roles[obj] = objRole
+				}
+			}
+		}
+		for _, n := range nested {
+			walk(n.ObjectGroups, n.Groups, groupRole(n.Name, n.Properties, parent))
+		}
+	}
+	walk(m.Src.ObjectGroups, m.Src.Groups, "")
+}
+
+// ObjectGroup will return the object group with the given name, wherever it
+// is on the map, or nil if there is no such group.
+func (m *Map) ObjectGroup(name string) *tiled.ObjectGroup {
+	for _, g := range m.ObjectGroups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// ObjectRole will return the role of the map object, empty if it has none.
+func (m *Map) ObjectRole(obj *tiled.Object) string {
+	return m.roles[obj]
+}
+
+// Objects will return every map object with the role, in map order.
+func (m *Map) Objects(role string) []*tiled.Object {
+	return m.filterObjects(func(obj *tiled.Object) bool {
+		return m.roles[obj] == role
+	})
+}
+
+// ObjectsOfType will return every map object with the Tiled type, in map
+// order.
+func (m *Map) ObjectsOfType(kind string) []*tiled.Object {
+	return m.filterObjects(func(obj *tiled.Object) bool {
+		return obj.Type == kind
+	})
+}
+
+// FindObject will return the first map object with the given name, or nil
+// if there is no such object.
+func (m *Map) FindObject(name string) *tiled.Object {
+	found := m.filterObjects(func(obj *tiled.Object) bool {
+		return obj.Name == name
+	})
+	if len(found) == 0 {
+		return nil
+	}
+	return found[0]
+}
+
+// filterObjects will return the map objects that pass the test.
+func (m *Map) filterObjects(test func(*tiled.Object) bool) []*tiled.Object {
+	found := make([]*tiled.Object, 0)
+	for _, g := range m.ObjectGroups {
+		for _, obj := range g.Objects {
+			if test(obj) {
+				found = append(found, obj)
+			}
+		}
+	}
+	return found
+}
+
+// ObjectShape will return the collision shape of the map object. Ellipses,
+// polygons and polylines keep their shape, anything else is a rect.
+// Polylines with the "onesided" property only block from one side. On
+// isometric maps rects and ellipses are squashed into polygons.
+func (m *Map) ObjectShape(obj *tiled.Object) Shape {
+	return objectShape(obj, false, m.ToWorld)
+}
+
+// objectShape will build the shape of a Tiled object, using place to turn
+// the Tiled top down positions into map positions. Mirrored shapes reverse
+// their polylines so they still block from the same side.
+func objectShape(obj *tiled.Object, mirrored bool, place func(pixel.Vec) pixel.Vec) Shape {
+	// Our points, relative to the object.
+	var points tiled.Points
+	if len(obj.PolyLines) > 0 && obj.PolyLines[0].Points != nil {
+		points = *obj.PolyLines[0].Points
+	} else if len(obj.Polygons) > 0 && obj.Polygons[0].Points != nil {
+		points = *obj.Polygons[0].Points
+	}
+	placed := make([]pixel.Vec, len(points))
+	for i, p := range points {
+		placed[i] = place(pixel.V(obj.X+p.X, obj.Y+p.Y))
+	}
+
+	switch {
+	case len(obj.Ellipses) > 0:
+		return ellipseShape(obj, place)
+	case len(obj.Polygons) > 0:
+		return &PolygonShape{Points: placed}
+	case len(obj.PolyLines) > 0:
+		if mirrored {
+			for i, j := 0, len(placed)-1; i < j; i, j = i+1, j-1 {
+				placed[i], placed[j] = placed[j], placed[i]
+			}
+		}
+		return &PolylineShape{Points: placed, OneSided: obj.Properties.GetBool("onesided")}
+	}
+
+	// Rects placed at an angle are no longer rects.
+	corners := []pixel.Vec{
+		place(pixel.V(obj.X, obj.Y)),
+		place(pixel.V(obj.X+obj.Width, obj.Y)),
+		place(pixel.V(obj.X+obj.Width, obj.Y+obj.Height)),
+		place(pixel.V(obj.X, obj.Y+obj.Height)),
+	}
+	if corners[0].Y != corners[1].Y || corners[0].X != corners[3].X {
+		return &PolygonShape{Points: corners}
+	}
+	return &RectShape{Rect: pixel.Rect{Min: corners[0], Max: corners[2]}.Norm()}
+}
+
+// ellipseShape will build the shape of a Tiled ellipse. Ellipses placed at
+// an angle become polygons following them.
+func ellipseShape(obj *tiled.Object, place func(pixel.Vec) pixel.Vec) Shape {
+	center := pixel.V(obj.X+obj.Width/2, obj.Y+obj.Height/2)
+	across := place(center).To(place(center.Add(pixel.V(obj.Width/2, 0))))
+	up := place(center).To(place(center.Add(pixel.V(0, obj.Height/2))))
+	if across.Y == 0 && up.X == 0 {
+		return &EllipseShape{Center: place(center), Radius: pixel.V(math.Abs(across.X), math.Abs(up.Y))}
+	}
+
+	shape := (&EllipseShape{Radius: pixel.V(1, 1)}).polygon()
+	for i, p := range shape.Points {
+		shape.Points[i] = place(center).Add(across.Scaled(p.X)).Add(up.Scaled(p.Y))
+	}
+	return shape
+}
@@ -0,0 +1,174 @@
+package gamesys
+
+import (
+	"image/color"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+	"golang.org/x/image/colornames"
+)
+
+// MapSettings are the map properties that set up the scene the map is
+// loaded into. Anything else is left for the game, found with
+// Map.CustomProperties.
+var MapSettings = []string{"background", "basespeed", "music", "ambient", "onenter", "encounters", "camera"}
+
+// Encounter is one entry of an encounter table, chosen by weight.
+type Encounter struct {
+	// Name is what is encountered, such as a monster group.
+	Name string
+
+	// Weight is how likely the encounter is, compared to the others.
+	Weight int
+}
+
+// EncounterTable is the list of things that can be run into on a map.
+type EncounterTable []Encounter
+
+// ParseEncounters will read an encounter table written as names with
+// optional weights, like "slime:3, bat:2, dragon". Names without a weight
+// have a weight of 1.
+func ParseEncounters(list string) EncounterTable {
+	table := make(EncounterTable, 0)
+	for _, entry := range strings.Split(list, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if parts[0] == "" {
+			continue
+		}
+
+		weight := 1
+		if len(parts) == 2 {
+			weight, _ = strconv.Atoi(strings.TrimSpace(parts[1]))
+		}
+		if weight > 0 {
+			table = append(table, Encounter{Name: parts[0], Weight: weight})
+		}
+	}
+	return table
+}
+
+// Pick will choose an encounter for the roll, from 0 up to 1. An empty table
+// gives an empty name.
+func (t EncounterTable) Pick(roll float64) string {
+	total := 0
+	for _, e := range t {
+		total += e.Weight
+	}
+
+	point := int(roll * float64(total))
+	for _, e := range t {
+		if point < e.Weight {
+			return e.Name
+		}
+		point -= e.Weight
+	}
+	return ""
+}
+
+// RollEncounter will choose a random encounter from the scene encounter
+// table, empty if there's nothing to encounter here.
+func (s *Scene) RollEncounter() string {
+	return s.Encounters.Pick(rand.Float64())
+}
+
+// ParseColor will read a colour by name, or in Tiled hex form being
+// #RRGGBB or #AARRGGBB.
+func ParseColor(value string) (color.RGBA, bool) {
+	if c, ok := colornames.Map[value]; ok {
+		return c, true
+	}
+
+	hex, err := tiled.ParseHexColor(value)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBAModel.Convert(&hex).(color.RGBA), true
+}
+
+// CustomProperties will return the map properties that aren't scene
+// settings, for the game to use as it likes.
+func (m *Map) CustomProperties() Properties {
+	custom := make(Properties)
+	for name, value := range m.Properties {
+		custom[name] = value
+	}
+	for _, name := range MapSettings {
+		delete(custom, name)
+	}
+	return custom
+}
+
+// useMapSettings will set up the scene from the map properties:
+//
+//	background  the scene background colour, also taken from the Tiled map
+//	            background colour
+//	basespeed   the scene base speed
+//	music       the music track, announced by a "music" event on entering
+//	ambient     the ambient light colour, tinting the whole scene
+//	onenter     the script run on entering the scene
+//	encounters  the encounter table, like "slime:3, bat:2"
+//	camera      the map object the cameras are kept within
+func (s *Scene) useMapSettings() {
+	m := s.MapData
+	if m.Src.BackgroundColor != nil {
+		s.Background = color.RGBAModel.Convert(m.Src.BackgroundColor).(color.RGBA)
+	}
+	if c, ok := ParseColor(m.Properties.String("background")); ok {
+		s.Background = c
+	}
+	s.Basespeed = s.Engine.Config.Default.Scene.Basespeed
+	if m.Properties.Has("basespeed") {
+		s.Basespeed = m.Properties.Float("basespeed")
+	}
+
+	s.Music = m.Properties.String("music")
+	s.Ambient, _ = ParseColor(m.Properties.String("ambient"))
+	s.OnEnter = m.Properties.String("onenter")
+	s.Encounters = ParseEncounters(m.Properties.String("encounters"))
+
+	// The camera bounds are an object, given by name or by Tiled object id.
+	s.CameraBounds = pixel.Rect{}
+	if camera := m.Properties.String("camera"); camera != "" {
+		if obj := m.FindObject(camera); obj != nil {
+			s.CameraBounds = m.ObjectRect(obj)
+		} else if obj := m.findObjectByID(camera); obj != nil {
+			s.CameraBounds = m.ObjectRect(obj)
+		}
+	}
+}
+
+// findObjectByID will return the map object with the Tiled object id, or
+// nil if there is no such object.
+func (m *Map) findObjectByID(id string) *tiled.Object {
+	for _, g := range m.ObjectGroups {
+		for _, obj := range g.Objects {
+			if strconv.FormatUint(uint64(obj.ID), 10) == id {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+// enter will announce the scene music and run the on enter script, as the
+// scene becomes the active scene. Music only starts when it changes.
+func (s *Scene) enter(previous *Scene) {
+	if s.Music != "" && (previous == nil || previous.Music != s.Music) {
+		s.Engine.Emit(&Event{Name: "music", Data: map[string]interface{}{"track": s.Music}})
+	}
+	if s.OnEnter != "" {
+		s.Engine.RunScriptFile(s.OnEnter)
+	}
+}
+
+// AmbientMask will return the colour the scene is tinted by, nil when there
+// is no ambient light.
+func (s *Scene) AmbientMask() color.Color {
+	if s.Ambient == (color.RGBA{}) {
+		return nil
+	}
+	return s.Ambient
+}
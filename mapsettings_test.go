@@ -0,0 +1,81 @@
+package gamesys
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapSettings(t *testing.T) {
+	e := testEngine
+	assert.NoError(t, e.NewScene("settings", "black"))
+	scene := e.Scenes["settings"]
+	defer func() {
+		delete(e.Scenes, "settings")
+		delete(e.Scenes, "entered")
+		e.ActivateScene("test1")
+	}()
+
+	assert.NoError(t, scene.LoadMap("test_assets/maps/settings.tmx"))
+	assert.Equal(t, color.RGBA{0x10, 0x20, 0x30, 0xff}, scene.Background, "The Tiled background colour is used.")
+	assert.Equal(t, 120.0, scene.Basespeed)
+	assert.Equal(t, "town.ogg", scene.Music)
+	assert.Equal(t, color.RGBA{0x80, 0x80, 0xa0, 0xff}, scene.Ambient)
+	assert.Equal(t, "entered", scene.OnEnter)
+	assert.Equal(t, EncounterTable{{"slime", 3}, {"bat", 1}}, scene.Encounters, "Nothing is encountered without weight.")
+	assert.Equal(t, pixel.R(32, 64, 224, 224), scene.CameraBounds)
+
+	// Everything else is left for the game.
+	assert.Equal(t, Properties{"region": "Lakeside", "safe": true}, scene.MapData.CustomProperties())
+	assert.Equal(t, "Lakeside", scene.MapData.Properties.String("region"))
+
+	// Entering the scene starts the music and runs the script.
+	var track interface{}
+	e.Listen("music", "settingstest", func(ev *Event) { track = ev.Data["track"] })
+	defer e.Unlisten("music", "settingstest")
+	e.ActivateScene("settings")
+	assert.Equal(t, "town.ogg", track)
+	assert.Contains(t, e.Scenes, "entered", "The on enter script should have run.")
+
+	// Another map without settings puts them back.
+	assert.NoError(t, scene.LoadMap("test_assets/maps/empty.tmx"))
+	assert.Equal(t, e.Config.Default.Scene.Basespeed, scene.Basespeed, "The base speed goes back to the default.")
+	assert.Empty(t, scene.Music)
+}
+
+func TestMapSettingsCamera(t *testing.T) {
+	scene := &Scene{CameraBounds: pixel.R(32, 64, 224, 224)}
+	view := &View{Scene: scene, Map: &Map{Size: pixel.V(256, 256)}, Camera: pixel.R(0, 0, 64, 64)}
+
+	view.CenterOn(pixel.ZV)
+	assert.Equal(t, pixel.R(32, 64, 96, 128), view.Camera, "The camera is kept within the bounds.")
+	view.CenterOn(pixel.V(500, 500))
+	assert.Equal(t, pixel.R(160, 160, 224, 224), view.Camera)
+}
+
+func TestEncounterTable(t *testing.T) {
+	table := ParseEncounters("slime:3, bat, ghost:0,")
+	assert.Equal(t, EncounterTable{{"slime", 3}, {"bat", 1}}, table)
+	assert.Equal(t, "slime", table.Pick(0))
+	assert.Equal(t, "slime", table.Pick(0.7))
+	assert.Equal(t, "bat", table.Pick(0.8))
+	assert.Empty(t, EncounterTable{}.Pick(0.5))
+
+	scene := &Scene{Encounters: table}
+	assert.Contains(t, []string{"slime", "bat"}, scene.RollEncounter())
+}
+
+func TestParseColor(t *testing.T) {
+	c, ok := ParseColor("red")
+	assert.True(t, ok)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, c)
+
+	c, ok = ParseColor("#80ff0000")
+	assert.True(t, ok)
+	assert.Equal(t, color.RGBA{255, 0, 0, 128}, c)
+
+	_, ok = ParseColor("nonsense")
+	assert.False(t, ok)
+}
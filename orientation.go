@@ -0,0 +1,263 @@
+package gamesys
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	// OrientationOrthogonal maps are laid out in square rows and columns.
+	OrientationOrthogonal = "orthogonal"
+
+	// OrientationIsometric maps are laid out in diamonds, with columns
+	// running down to the right and rows down to the left.
+	OrientationIsometric = "isometric"
+
+	// OrientationStaggered maps are laid out in diamonds, with every other
+	// row or column shifted by half a tile.
+	OrientationStaggered = "staggered"
+
+	// OrientationHexagonal maps are laid out in hexagons, with every other
+	// row or column shifted by half a tile.
+	OrientationHexagonal = "hexagonal"
+)
+
+// MapGrid is how the tiles of a map are laid out. It works in Tiled pixels,
+// from the top left of the map going down, like the positions in map files.
+// Map turns these into map positions.
+type MapGrid struct {
+	// Orientation is the Tiled map orientation.
+	Orientation string
+
+	// Columns and Rows are how many tiles the map has across and down.
+	Columns int
+	Rows    int
+
+	// TileSize is the size of the box around each tile.
+	TileSize pixel.Vec
+
+	// StaggerX shifts every other column down, rather than every other row
+	// across, on staggered and hexagonal maps.
+	StaggerX bool
+
+	// StaggerEven shifts the even rows or columns rather than the odd ones.
+	StaggerEven bool
+
+	// HexSide is the length of the flat hexagon sides, along the staggered
+	// axis.
+	HexSide float64
+}
+
+// staggered will indicate if the grid shifts every other row or column.
+func (g *MapGrid) staggered() bool {
+	return g.Orientation == OrientationStaggered || g.Orientation == OrientationHexagonal
+}
+
+// shifted will indicate if the row or column is moved by half a tile.
+func (g *MapGrid) shifted(index int) bool {
+	return (index&1 == 1) != g.StaggerEven
+}
+
+// side will return the length of the flat hexagon sides, none for
+// staggered maps.
+func (g *MapGrid) side() float64 {
+	if g.Orientation == OrientationHexagonal {
+		return g.HexSide
+	}
+	return 0
+}
+
+// step will return how far apart neighbouring columns and rows are, along
+// the staggered axis being less than a whole tile.
+func (g *MapGrid) step() pixel.Vec {
+	step := g.TileSize
+	if g.staggered() {
+		if g.StaggerX {
+			step.X = (g.TileSize.X + g.side()) / 2
+		} else {
+			step.Y = (g.TileSize.Y + g.side()) / 2
+		}
+	}
+	return step
+}
+
+// Size will return the size of the whole map, in pixels.
+func (g *MapGrid) Size() pixel.Vec {
+	columns, rows := float64(g.Columns), float64(g.Rows)
+	switch {
+	case g.Orientation == OrientationIsometric:
+		return pixel.V((columns+rows)*g.TileSize.X/2, (columns+rows)*g.TileSize.Y/2)
+	case g.staggered() && g.StaggerX:
+		return pixel.V(columns*g.step().X+(g.TileSize.X-g.side())/2, rows*g.TileSize.Y+g.TileSize.Y/2)
+	case g.staggered():
+		return pixel.V(columns*g.TileSize.X+g.TileSize.X/2, rows*g.step().Y+(g.TileSize.Y-g.side())/2)
+	}
+	return pixel.V(columns*g.TileSize.X, rows*g.TileSize.Y)
+}
+
+// CellOrigin will return the top left corner of the box around the tile.
+func (g *MapGrid) CellOrigin(column int, row int) pixel.Vec {
+	c, r := float64(column), float64(row)
+	tile, step := g.TileSize, g.step()
+	switch {
+	case g.Orientation == OrientationIsometric:
+		return pixel.V((c-r-1)*tile.X/2+float64(g.Rows)*tile.X/2, (c+r)*tile.Y/2)
+	case g.staggered() && g.StaggerX:
+		origin := pixel.V(c*step.X, r*tile.Y)
+		if g.shifted(column) {
+			origin.Y += tile.Y / 2
+		}
+		return origin
+	case g.staggered():
+		origin := pixel.V(c*tile.X, r*step.Y)
+		if g.shifted(row) {
+			origin.X += tile.X / 2
+		}
+		return origin
+	}
+	return pixel.V(c*tile.X, r*tile.Y)
+}
+
+// CellPolygon will return the corners of the tile, going clockwise from
+// the top left or top.
+func (g *MapGrid) CellPolygon(column int, row int) []pixel.Vec {
+	o := g.CellOrigin(column, row)
+	w, h := g.TileSize.X, g.TileSize.Y
+	side := g.side()
+
+	var corners []pixel.Vec
+	switch {
+	case g.Orientation == OrientationIsometric || (g.staggered() && side == 0):
+		corners = []pixel.Vec{{X: w / 2, Y: 0}, {X: w, Y: h / 2}, {X: w / 2, Y: h}, {X: 0, Y: h / 2}}
+	case g.staggered() && g.StaggerX:
+		edge := (w - side) / 2
+		corners = []pixel.Vec{{X: edge, Y: 0}, {X: edge + side, Y: 0}, {X: w, Y: h / 2}, {X: edge + side, Y: h}, {X: edge, Y: h}, {X: 0, Y: h / 2}}
+	case g.staggered():
+		edge := (h - side) / 2
+		corners = []pixel.Vec{{X: w / 2, Y: 0}, {X: w, Y: edge}, {X: w, Y: edge + side}, {X: w / 2, Y: h}, {X: 0, Y: edge + side}, {X: 0, Y: edge}}
+	default:
+		corners = []pixel.Vec{{X: 0, Y: 0}, {X: w, Y: 0}, {X: w, Y: h}, {X: 0, Y: h}}
+	}
+
+	for i := range corners {
+		corners[i] = corners[i].Add(o)
+	}
+	return corners
+}
+
+// TileAt will return the column and row of the tile holding the pixel.
+// Places off the map give tiles off the map too.
+func (g *MapGrid) TileAt(p pixel.Vec) (int, int) {
+	tile := g.TileSize
+	switch {
+	case g.Orientation == OrientationIsometric:
+		x := (p.X - float64(g.Rows)*tile.X/2) / tile.X
+		y := p.Y / tile.Y
+		return int(math.Floor(y + x)), int(math.Floor(y - x))
+	case g.staggered():
+		return g.staggeredTileAt(p)
+	}
+	return int(math.Floor(p.X / tile.X)), int(math.Floor(p.Y / tile.Y))
+}
+
+// staggeredTileAt will find the tile holding the pixel on a staggered or
+// hexagonal map, checking the tiles around the rough guess.
+func (g *MapGrid) staggeredTileAt(p pixel.Vec) (int, int) {
+	step := g.step()
+	column, row := int(math.Floor(p.X/step.X)), int(math.Floor(p.Y/step.Y))
+
+	best, bestColumn, bestRow := math.Inf(1), column, row
+	for r := row - 1; r <= row+1; r++ {
+		for c := column - 1; c <= column+1; c++ {
+			if (&PolygonShape{Points: g.CellPolygon(c, r)}).Contains(p) {
+				return c, r
+			}
+
+			// Between tiles, such as on an edge, the closest wins.
+			o := g.CellOrigin(c, r)
+			d := pixel.V((p.X-o.X)/g.TileSize.X-0.5, (p.Y-o.Y)/g.TileSize.Y-0.5).Len()
+			if d < best {
+				best, bestColumn, bestRow = d, c, r
+			}
+		}
+	}
+	return bestColumn, bestRow
+}
+
+// Neighbours will return the tiles sharing an edge with the tile, going
+// clockwise.
+func (g *MapGrid) Neighbours(column int, row int) [][2]int {
+	corners := g.CellPolygon(column, row)
+	o := g.CellOrigin(column, row)
+	center := o.Add(g.TileSize.Scaled(0.5))
+
+	// Each neighbour sits just as far past the middle of the edge.
+	neighbours := make([][2]int, len(corners))
+	for i := range corners {
+		middle := corners[i].Add(corners[(i+1)%len(corners)]).Scaled(0.5)
+		c, r := g.TileAt(center.Add(center.To(middle).Scaled(1.5)))
+		neighbours[i] = [2]int{c, r}
+	}
+	return neighbours
+}
+
+// ObjectPixel will return where an object position from the map file is
+// drawn. Isometric maps place their objects along the tile axes, each tile
+// being a tile height along, while other maps use pixels already.
+func (g *MapGrid) ObjectPixel(p pixel.Vec) pixel.Vec {
+	if g.Orientation != OrientationIsometric {
+		return p
+	}
+
+	x, y := p.X/g.TileSize.Y, p.Y/g.TileSize.Y
+	return pixel.V((x-y)*g.TileSize.X/2+float64(g.Rows)*g.TileSize.X/2, (x+y)*g.TileSize.Y/2)
+}
+
+// TileOffset will return how far the object positions in the map file
+// move when tiles move by the given columns and rows.
+func (g *MapGrid) TileOffset(columns int, rows int) pixel.Vec {
+	c, r := float64(columns), float64(rows)
+	if g.Orientation == OrientationIsometric {
+		return pixel.V(c*g.TileSize.Y, r*g.TileSize.Y)
+	}
+	step := g.step()
+	return pixel.V(c*step.X, r*step.Y)
+}
+
+// TileToWorld will return the map position of the middle of the tile.
+func (m *Map) TileToWorld(column int, row int) pixel.Vec {
+	return m.TileRect(column, row).Center()
+}
+
+// WorldToTile will return the column and row of the tile holding the map
+// position, counting rows from the top like Tiled does.
+func (m *Map) WorldToTile(position pixel.Vec) (int, int) {
+	return m.Grid.TileAt(m.toPixel(position))
+}
+
+// TileShape will return the shape of the tile on the map, being a rect,
+// diamond or hexagon.
+func (m *Map) TileShape(column int, row int) Shape {
+	if m.Grid.Orientation == OrientationOrthogonal || m.Grid.Orientation == "" {
+		return &RectShape{Rect: m.TileRect(column, row)}
+	}
+
+	corners := m.Grid.CellPolygon(column, row)
+	points := make([]pixel.Vec, len(corners))
+	for i, c := range corners {
+		points[i] = m.toPixel(c)
+	}
+	return &PolygonShape{Points: points}
+}
+
+// ToWorld will turn a position from the map file into a map position.
+func (m *Map) ToWorld(p pixel.Vec) pixel.Vec {
+	return m.toPixel(m.Grid.ObjectPixel(p))
+}
+
+// toPixel will flip between Tiled pixels and map positions, Tiled going
+// down from the top and maps going up from the bottom.
+func (m *Map) toPixel(p pixel.Vec) pixel.Vec {
+	return pixel.V(p.X, m.Size.Y-p.Y)
+}
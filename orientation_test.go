@@ -0,0 +1,153 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsometricMap(t *testing.T) {
+	m, err := NewMap("test_assets/maps/isometric.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, pixel.V(256, 128), m.Size, "Isometric maps are as wide as their columns and rows together.")
+
+	// The first tile is at the top, the last at the bottom.
+	assert.Equal(t, pixel.R(96, 96, 160, 128), m.TileRect(0, 0))
+	assert.Equal(t, pixel.V(128, 16), m.TileToWorld(3, 3))
+	assert.Equal(t, pixel.V(224, 64), m.TileToWorld(3, 0))
+	for _, tile := range [][2]int{{0, 0}, {1, 2}, {3, 0}, {2, 3}} {
+		column, row := m.WorldToTile(m.TileToWorld(tile[0], tile[1]))
+		assert.Equal(t, tile, [2]int{column, row}, "Tiles should convert both ways.")
+	}
+	column, row := m.WorldToTile(pixel.V(97, 97))
+	assert.Equal(t, [2]int{0, 1}, [2]int{column, row}, "The corner of a tile box belongs to the next diamond over.")
+
+	// Objects run along the tile axes.
+	assert.Equal(t, pixel.V(160, 96), m.ToWorld(pixel.V(48, 16)))
+	assert.Equal(t, pixel.R(96, 96, 160, 128), m.ObjectRect(m.FindObject("Crate")))
+	assert.Equal(t, []Shape{&PolygonShape{Points: []pixel.Vec{{X: 128, Y: 128}, {X: 160, Y: 112}, {X: 128, Y: 96}, {X: 96, Y: 112}}}}, m.Collision, "Rects become diamonds.")
+	assert.Equal(t, &PolygonShape{Points: []pixel.Vec{{X: 128, Y: 128}, {X: 160, Y: 112}, {X: 128, Y: 96}, {X: 96, Y: 112}}}, m.TileShape(0, 0))
+
+	// Tiles sit on the bottom left of their box.
+	layer := m.Src.Layers[0]
+	matrix := m.Tiles.tileMatrix(layer, 0, 0, layer.Tiles[0], pixel.V(64, 32))
+	assert.Equal(t, pixel.V(128, 112), matrix.Project(pixel.ZV))
+	assert.Len(t, m.Tiles.visibleChunks(pixel.R(0, 0, 256, 128), 0), 1)
+	assert.Empty(t, m.Tiles.visibleChunks(pixel.R(1000, 1000, 1100, 1100), 0), "Nothing is drawn off the map.")
+}
+
+func TestStaggeredMap(t *testing.T) {
+	m, err := NewMap("test_assets/maps/staggered.tmx")
+	if !assert.NoError(t, err, "Stagger settings should be read.") {
+		return
+	}
+	assert.False(t, m.Grid.StaggerX)
+	assert.False(t, m.Grid.StaggerEven)
+	assert.Equal(t, pixel.V(288, 80), m.Size)
+
+	// Odd rows are shifted across by half a tile.
+	assert.Equal(t, pixel.V(32, 64), m.TileToWorld(0, 0))
+	assert.Equal(t, pixel.V(64, 48), m.TileToWorld(0, 1))
+	column, row := m.WorldToTile(pixel.V(64, 48))
+	assert.Equal(t, [2]int{0, 1}, [2]int{column, row})
+	assert.Equal(t, pixel.V(64, 48), m.ToWorld(pixel.V(64, 32)), "Objects are in pixels.")
+
+	assert.Equal(t, [][2]int{{1, 1}, {1, 3}, {0, 3}, {0, 1}}, m.Grid.Neighbours(1, 2))
+}
+
+func TestHexagonalMap(t *testing.T) {
+	m, err := NewMap("test_assets/maps/hexagonal.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, pixel.V(144, 104), m.Size)
+
+	// Rows overlap by the slanted edges.
+	assert.Equal(t, pixel.R(48, 48, 80, 80), m.TileRect(1, 1))
+	column, row := m.WorldToTile(m.ToWorld(pixel.V(64, 40)))
+	assert.Equal(t, [2]int{1, 1}, [2]int{column, row})
+	column, row = m.WorldToTile(pixel.V(50, 50))
+	assert.Equal(t, [2]int{1, 2}, [2]int{column, row}, "The corners of a box belong to the hexagons around it.")
+	assert.Len(t, m.TileShape(1, 1).(*PolygonShape).Points, 6)
+
+	assert.Equal(t, [][2]int{{2, 0}, {2, 1}, {2, 2}, {1, 2}, {0, 1}, {1, 0}}, m.Grid.Neighbours(1, 1))
+}
+
+func TestIsometricGridMovement(t *testing.T) {
+	m, err := NewMap("test_assets/maps/isometric.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), MapData: m, Movement: MovementGrid}
+
+	// Each direction steps onto a different diamond.
+	for direction, target := range map[int]pixel.Vec{0: {X: 160, Y: 96}, 90: {X: 96, Y: 96}, 180: {X: 96, Y: 64}, 270: {X: 160, Y: 64}} {
+		actor := newTestActor("walker", m.TileToWorld(1, 1))
+		scene.MoveActor(actor, direction)
+		assert.Equal(t, []pixel.Vec{target}, actor.Destinations, "Stepping toward %d degrees.", direction)
+	}
+}
+
+func TestIsometricNavGrid(t *testing.T) {
+	m, err := NewMap("test_assets/maps/isometric.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), MapData: m}
+	grid := scene.NavGrid(false, nil)
+
+	// Only the corners of the nav grid are off the diamond.
+	for _, corner := range [][2]int{{0, 0}, {3, 0}, {0, 3}, {3, 3}} {
+		assert.False(t, grid.Walkable(corner[0], corner[1]), "The corner %v is off the map.", corner)
+	}
+	assert.True(t, grid.Walkable(1, 1), "The middle of the map is walkable.")
+	assert.False(t, grid.Walkable(1, 3), "The crate blocks the top.")
+
+	// Paths stay on the map.
+	path, err := grid.FindPath(m.TileToWorld(3, 0), m.TileToWorld(0, 3), PathOptions{})
+	if assert.NoError(t, err) {
+		for _, p := range path {
+			column, row := m.WorldToTile(p)
+			assert.True(t, column >= 0 && row >= 0 && column < 4 && row < 4, "%v should be on the map.", p)
+		}
+	}
+}
+
+func TestIsometricDrawOrder(t *testing.T) {
+	m, err := NewMap("test_assets/maps/isometric.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), MapData: m}
+	view := &View{Scene: scene, Camera: pixel.R(0, 0, 256, 128), YSort: true}
+
+	// Tiles further down the columns and rows are in front.
+	actor := func(id string, column int, row int) *Actor {
+		a := newTestActor(id, m.TileToWorld(column, row))
+		scene.Actors[id] = a
+		view.VisibleActors = append(view.VisibleActors, id)
+		return a
+	}
+	front := actor("front", 2, 2)
+	right := actor("right", 3, 0)
+	back := actor("back", 0, 0)
+	left := actor("left", 0, 1)
+
+	assert.Equal(t, []*Actor{back, left, right, front}, view.DrawOrder(), "Actors further back are drawn first.")
+}
+
+func TestIsometricSpawns(t *testing.T) {
+	e := testEngine
+	assert.NoError(t, e.NewScene("isometric", "black"))
+	defer delete(e.Scenes, "isometric")
+	defer delete(e.Actors, "isoguard")
+
+	scene := e.Scenes["isometric"]
+	assert.NoError(t, scene.LoadMap("test_assets/maps/isometric.tmx"))
+	if guard := scene.Actors["isoguard"]; assert.NotNil(t, guard) {
+		assert.Equal(t, pixel.V(160, 96), guard.Position, "The guard should spawn in the middle of their tile.")
+	}
+}
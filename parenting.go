@@ -0,0 +1,67 @@
+package gamesys
+
+import (
+	"errors"
+
+	"github.com/faiface/pixel"
+)
+
+// Attach will attach the actor to the parent at the offset, such as a sword
+// held in a hand or a shadow underneath. Any previous parent is dropped.
+// Attaching to ourselves or one of our own children is rejected.
+func (a *Actor) Attach(parent *Actor, offset pixel.Vec) error {
+	for p := parent; p != nil; p = p.Parent {
+		if p == a {
+			return errors.New("attach: actor would be its own parent")
+		}
+	}
+
+	a.Detach()
+	a.Parent = parent
+	a.Offset = offset
+	parent.Children = append(parent.Children, a)
+
+	// Move straight to our spot on the parent.
+	a.Position = parent.Matrix().Project(offset)
+	a.SetClip()
+
+	return nil
+}
+
+// Detach will drop the actor from its parent, leaving it where it is.
+func (a *Actor) Detach() {
+	if a.Parent == nil {
+		return
+	}
+
+	children := make([]*Actor, 0)
+	for _, c := range a.Parent.Children {
+		if c != a {
+			children = append(children, c)
+		}
+	}
+	a.Parent.Children = children
+	a.Parent = nil
+	a.Offset = pixel.ZV
+}
+
+// Root will return the top most parent of the actor, which is the actor
+// itself when not attached.
+func (a *Actor) Root() *Actor {
+	root := a
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return root
+}
+
+// IsVisible will indicate if the actor is shown, which needs every parent
+// to be shown as well.
+func (a *Actor) IsVisible() bool {
+	for p := a; p != nil; p = p.Parent {
+		if !p.Visible {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestActor will make a plain actor for tests, without needing images.
+func newTestActor(id string, position pixel.Vec) *Actor {
+	actor := &Actor{ID: id, Src: pixel.MakePictureData(pixel.R(0, 0, 10, 10)), Position: position, Visible: true}
+	actor.Render()
+	return actor
+}
+
+func TestActorAttach(t *testing.T) {
+	hero := newTestActor("hero", pixel.V(100, 100))
+	sword := newTestActor("sword", pixel.ZV)
+	gem := newTestActor("gem", pixel.ZV)
+
+	assert.NoError(t, sword.Attach(hero, pixel.V(10, 0)))
+	assert.NoError(t, gem.Attach(sword, pixel.V(0, 5)))
+	assert.Equal(t, pixel.V(110, 105), gem.Position, "We should sit at our offset from every parent.")
+	assert.Error(t, hero.Attach(gem, pixel.ZV), "Cycles should be rejected.")
+	assert.Error(t, hero.Attach(hero, pixel.ZV), "We can't be our own parent.")
+
+	hero.MoveTo(pixel.V(200, 200))
+	assert.Equal(t, pixel.V(210, 200), sword.Position, "Children should follow.")
+	assert.Equal(t, pixel.R(205, 195, 215, 205), sword.Clip, "Child clips should follow.")
+
+	hero.SetRotation(90)
+	assert.InDelta(t, 200, sword.Position.X, 1e-9, "Children should swing around with us.")
+	assert.InDelta(t, 210, sword.Position.Y, 1e-9, "Children should swing around with us.")
+	hero.SetRotation(0)
+
+	hero.SetAlpha(0.5)
+	assert.Equal(t, 0.5, gem.ColorMask().A, "Children should fade with us.")
+
+	hero.Hide()
+	assert.False(t, gem.IsVisible(), "Children should hide with us.")
+	assert.False(t, sword.CollidesWith(hero), "We never collide with our family.")
+
+	sword.Detach()
+	assert.Nil(t, sword.Parent)
+	assert.Empty(t, hero.Children, "We should be removed from our parent.")
+	hero.MoveTo(pixel.V(0, 0))
+	assert.Equal(t, pixel.V(210, 200), sword.Position, "Detached actors stay put.")
+}
+
+func TestActorAttachActions(t *testing.T) {
+	hero := newTestActor("hero", pixel.V(100, 100))
+	sword := newTestActor("sword", pixel.ZV)
+	actionScene("parenting", hero, sword)
+	defer delete(testEngine.Scenes, "parenting")
+
+	assert.NoError(t, runAction("ActorAttach", "parenting", "sword", "hero", "10", "0"))
+	assert.Equal(t, hero, sword.Parent, "We should be attached.")
+	assert.Error(t, runAction("ActorAttach", "parenting", "sword", "nobody", "10", "0"), "Unknown parents should be an error.")
+	assert.Error(t, runAction("ActorAttach", "parenting", "nobody", "hero", "10", "0"), "Unknown actors should be an error.")
+
+	assert.NoError(t, runAction("ActorDetach", "parenting", "sword"))
+	assert.Nil(t, sword.Parent, "We should be detached.")
+	assert.Error(t, runAction("ActorDetach", "parenting", "nobody"), "Unknown actors should be an error.")
+}
@@ -0,0 +1,388 @@
+package gamesys
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+var (
+	// ErrUnreachable is returned when there is no path to the target.
+	ErrUnreachable = errors.New("pathfinding: target unreachable")
+)
+
+// PathOptions control how a path is found.
+type PathOptions struct {
+	// Diagonal allows diagonal steps. Corners are never cut.
+	Diagonal bool
+
+	// Smooth removes waypoints that can be skipped with a straight line.
+	Smooth bool
+
+	// Actors treats other solid actors as blocked tiles.
+	Actors bool
+}
+
+// NavGrid is a grid of tiles marking where actors can walk, used to find
+// paths around the map.
+type NavGrid struct {
+	// Columns is the number of tiles across.
+	Columns int
+
+	// Rows is the number of tiles up.
+	Rows int
+
+	// TileSize is the size of each tile.
+	TileSize pixel.Vec
+
+	// Blocked marks the tiles that can't be walked through, by row then
+	// column, starting from the bottom left.
+	Blocked []bool
+}
+
+// NewNavGrid will create an empty grid with every tile walkable.
+func NewNavGrid(columns int, rows int, tileSize pixel.Vec) *NavGrid {
+	return &NavGrid{Columns: columns, Rows: rows, TileSize: tileSize, Blocked: make([]bool, columns*rows)}
+}
+
+// Copy will return a copy of the grid, so it can be changed freely.
+func (n *NavGrid) Copy() *NavGrid {
+	newGrid := NewNavGrid(n.Columns, n.Rows, n.TileSize)
+	copy(newGrid.Blocked, n.Blocked)
+	return newGrid
+}
+
+// Block will mark every tile the area overlaps as blocked. Areas only
+// touching the edge of a tile don't count.
+func (n *NavGrid) Block(area pixel.Rect) {
+	minC := int(math.Floor(area.Min.X / n.TileSize.X))
+	minR := int(math.Floor(area.Min.Y / n.TileSize.Y))
+	maxC := int(math.Ceil(area.Max.X/n.TileSize.X)) - 1
+	maxR := int(math.Ceil(area.Max.Y/n.TileSize.Y)) - 1
+
+	for r := minR; r <= maxR; r++ {
+		for c := minC; c <= maxC; c++ {
+			if n.inside(c, r) {
+				n.Blocked[r*n.Columns+c] = true
+			}
+		}
+	}
+}
+
+// BlockShape will mark every tile the shape overlaps as blocked.
+func (n *NavGrid) BlockShape(shape Shape) {
+	if r, ok := shape.(*RectShape); ok {
+		n.Block(r.Rect)
+		return
+	}
+
+	// Check each tile under the shape, as it may not fill them all.
+	bounds := shape.Bounds()
+	minC, minR := n.Tile(bounds.Min)
+	maxC, maxR := n.Tile(bounds.Max)
+	for r := minR; r <= maxR; r++ {
+		for c := minC; c <= maxC; c++ {
+			tile := pixel.R(float64(c)*n.TileSize.X, float64(r)*n.TileSize.Y, float64(c+1)*n.TileSize.X, float64(r+1)*n.TileSize.Y)
+			if n.inside(c, r) && shape.IntersectsRect(tile) {
+				n.Blocked[r*n.Columns+c] = true
+			}
+		}
+	}
+}
+
+// BlockOutside will mark every tile that no shape overlaps as blocked, such
+// as the corners around an isometric map.
+func (n *NavGrid) BlockOutside(shapes []Shape) {
+	covered := make([]bool, len(n.Blocked))
+	for _, shape := range shapes {
+		bounds := shape.Bounds()
+		minC, minR := n.Tile(bounds.Min)
+		maxC, maxR := n.Tile(bounds.Max)
+		for r := minR; r <= maxR; r++ {
+			for c := minC; c <= maxC; c++ {
+				tile := pixel.R(float64(c)*n.TileSize.X, float64(r)*n.TileSize.Y, float64(c+1)*n.TileSize.X, float64(r+1)*n.TileSize.Y)
+				if n.inside(c, r) && shape.IntersectsRect(tile) {
+					covered[r*n.Columns+c] = true
+				}
+			}
+		}
+	}
+
+	for i := range n.Blocked {
+		if !covered[i] {
+			n.Blocked[i] = true
+		}
+	}
+}
+
+// Tile will return the column and row holding the position.
+func (n *NavGrid) Tile(position pixel.Vec) (int, int) {
+	return int(math.Floor(position.X / n.TileSize.X)), int(math.Floor(position.Y / n.TileSize.Y))
+}
+
+// Center will return the middle of the tile.
+func (n *NavGrid) Center(column int, row int) pixel.Vec {
+	return pixel.V((float64(column)+0.5)*n.TileSize.X, (float64(row)+0.5)*n.TileSize.Y)
+}
+
+// Walkable will indicate if the tile is on the grid and not blocked.
+func (n *NavGrid) Walkable(column int, row int) bool {
+	return n.inside(column, row) && !n.Blocked[row*n.Columns+column]
+}
+
+// inside will indicate if the tile is on the grid.
+func (n *NavGrid) inside(column int, row int) bool {
+	return column >= 0 && row >= 0 && column < n.Columns && row < n.Rows
+}
+
+// FindPath will find the shortest path between two positions using A*. The
+// path is made of tile centres, ending exactly on the target, and does not
+// include the starting position.
+func (n *NavGrid) FindPath(from pixel.Vec, to pixel.Vec, options PathOptions) ([]pixel.Vec, error) {
+	startC, startR := n.Tile(from)
+	endC, endR := n.Tile(to)
+
+	if !n.inside(startC, startR) || !n.Walkable(endC, endR) {
+		return nil, ErrUnreachable
+	}
+
+	start := startR*n.Columns + startC
+	end := endR*n.Columns + endC
+
+	// Our neighbouring steps, with diagonals last.
+	steps := [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	if !options.Diagonal {
+		steps = steps[:4]
+	}
+
+	cost := map[int]float64{start: 0}
+	came := map[int]int{}
+	open := &pathQueue{}
+	heap.Push(open, &pathNode{tile: start, priority: n.heuristic(start, end, options.Diagonal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*pathNode).tile
+		if current == end {
+			return n.buildPath(came, start, end, to, options.Smooth), nil
+		}
+
+		c, r := current%n.Columns, current/n.Columns
+		for _, s := range steps {
+			nc, nr := c+s[0], r+s[1]
+			if !n.Walkable(nc, nr) {
+				continue
+			}
+
+			// Don't cut corners around blocked tiles.
+			if s[0] != 0 && s[1] != 0 && (!n.Walkable(c+s[0], r) || !n.Walkable(c, r+s[1])) {
+				continue
+			}
+
+			next := nr*n.Columns + nc
+			newCost := cost[current] + math.Hypot(float64(s[0]), float64(s[1]))
+			if known, ok := cost[next]; !ok || newCost < known {
+				cost[next] = newCost
+				came[next] = current
+				heap.Push(open, &pathNode{tile: next, priority: newCost + n.heuristic(next, end, options.Diagonal)})
+			}
+		}
+	}
+
+	return nil, ErrUnreachable
+}
+
+// heuristic will estimate the cost between two tiles.
+func (n *NavGrid) heuristic(a int, b int, diagonal bool) float64 {
+	dx := math.Abs(float64(a%n.Columns - b%n.Columns))
+	dy := math.Abs(float64(a/n.Columns - b/n.Columns))
+
+	if diagonal {
+		return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+	}
+	return dx + dy
+}
+
+// buildPath will walk back through the found tiles to make our waypoints.
+func (n *NavGrid) buildPath(from map[int]int, start int, end int, target pixel.Vec, smooth bool) []pixel.Vec {
+	// Already on the right tile, so just step over to the target.
+	if start == end {
+		return []pixel.Vec{target}
+	}
+
+	tiles := []int{end}
+	for tiles[0] != start {
+		tiles = append([]int{from[tiles[0]]}, tiles...)
+	}
+
+	path := make([]pixel.Vec, len(tiles))
+	for i, t := range tiles {
+		path[i] = n.Center(t%n.Columns, t/n.Columns)
+	}
+	path[len(path)-1] = target
+
+	if smooth {
+		path = n.smooth(path)
+	}
+
+	// We are already standing on the first tile.
+	return path[1:]
+}
+
+// smooth will skip any waypoints we can see past, keeping the path as
+// straight as possible.
+func (n *NavGrid) smooth(path []pixel.Vec) []pixel.Vec {
+	if len(path) < 3 {
+		return path
+	}
+
+	smoothed := []pixel.Vec{path[0]}
+	for i := 1; i < len(path)-1; i++ {
+		if !n.LineOfSight(smoothed[len(smoothed)-1], path[i+1]) {
+			smoothed = append(smoothed, path[i])
+		}
+	}
+
+	return append(smoothed, path[len(path)-1])
+}
+
+// LineOfSight will indicate if a straight line between two positions only
+// passes through walkable tiles.
+func (n *NavGrid) LineOfSight(from pixel.Vec, to pixel.Vec) bool {
+	c, r := n.Tile(from)
+	endC, endR := n.Tile(to)
+	direction := from.To(to)
+
+	// Walk the tiles the line crosses, one boundary at a time.
+	stepC, stepR := 1, 1
+	if direction.X < 0 {
+		stepC = -1
+	}
+	if direction.Y < 0 {
+		stepR = -1
+	}
+
+	// How far along the line the next boundary is, and between boundaries.
+	next := func(position float64, tile int, step int, size float64, delta float64) (float64, float64) {
+		if delta == 0 {
+			return math.Inf(1), math.Inf(1)
+		}
+		boundary := float64(tile) * size
+		if step > 0 {
+			boundary += size
+		}
+		return (boundary - position) / delta, size / math.Abs(delta)
+	}
+	maxC, deltaC := next(from.X, c, stepC, n.TileSize.X, direction.X)
+	maxR, deltaR := next(from.Y, r, stepR, n.TileSize.Y, direction.Y)
+
+	for {
+		if !n.Walkable(c, r) {
+			return false
+		}
+		if c == endC && r == endR {
+			return true
+		}
+
+		// The line ends before reaching another tile.
+		if maxC > 1 && maxR > 1 {
+			return true
+		}
+
+		// Crossing a corner exactly means checking both neighbours.
+		switch {
+		case maxC < maxR:
+			maxC += deltaC
+			c += stepC
+		case maxR < maxC:
+			maxR += deltaR
+			r += stepR
+		default:
+			if !n.Walkable(c+stepC, r) || !n.Walkable(c, r+stepR) {
+				return false
+			}
+			maxC += deltaC
+			maxR += deltaR
+			c += stepC
+			r += stepR
+		}
+	}
+}
+
+// pathNode is a tile waiting to be explored.
+type pathNode struct {
+	tile     int
+	priority float64
+}
+
+// pathQueue keeps our waiting tiles with the cheapest first.
+type pathQueue []*pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(*pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
+
+// NavGrid will return the navigation grid for the scene map, built from the
+// map collision areas. Places off the map tiles are blocked, which matters
+// on maps that aren't square. With actors included, other solid actors are blocked
+// too, apart from the given actor.
+func (s *Scene) NavGrid(actors bool, except *Actor) *NavGrid {
+	if s.navGrid == nil {
+		// The grid covers the whole map in square tiles, whatever shape the
+		// map tiles are.
+		tile := s.TileSize()
+		s.navGrid = NewNavGrid(int(math.Ceil(s.MapData.Size.X/tile.X)), int(math.Ceil(s.MapData.Size.Y/tile.Y)), tile)
+		for _, c := range s.MapData.Collision {
+			s.navGrid.BlockShape(c)
+		}
+
+		// Maps that aren't square have corners with no tiles to walk on.
+		if grid := s.MapData.Grid; grid.Orientation != OrientationOrthogonal && grid.Orientation != "" {
+			tiles := make([]Shape, 0, grid.Columns*grid.Rows)
+			for r := 0; r < grid.Rows; r++ {
+				for c := 0; c < grid.Columns; c++ {
+					tiles = append(tiles, s.MapData.TileShape(c, r))
+				}
+			}
+			s.navGrid.BlockOutside(tiles)
+		}
+	}
+
+	if !actors {
+		return s.navGrid
+	}
+
+	grid := s.navGrid.Copy()
+	for _, a := range s.Actors {
+		if a != except && except.Blocks(a) {
+			grid.Block(a.Clip)
+		}
+	}
+
+	return grid
+}
+
+// PathActor will find a path for the actor to the target, and send the
+// actor along it. ErrUnreachable is returned when there is no way there,
+// leaving the actor where it is.
+func (s *Scene) PathActor(actor *Actor, target pixel.Vec, options PathOptions) error {
+	if s.MapData == nil {
+		return errors.New("pathactor: there is no mapdata to path on")
+	}
+
+	path, err := s.NavGrid(options.Actors, actor).FindPath(actor.Position, target, options)
+	if err != nil {
+		return err
+	}
+
+	actor.Destinations = path
+	return nil
+}
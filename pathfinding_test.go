@@ -0,0 +1,66 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+// testGrid is a 5x5 grid of 10 pixel tiles with a wall up the middle,
+// leaving a gap at the top.
+func testGrid() *NavGrid {
+	grid := NewNavGrid(5, 5, pixel.V(10, 10))
+	grid.Block(pixel.R(20, 0, 30, 40))
+	return grid
+}
+
+func TestNavGridBlock(t *testing.T) {
+	grid := testGrid()
+
+	assert.False(t, grid.Walkable(2, 0), "The wall should be blocked.")
+	assert.True(t, grid.Walkable(2, 4), "The gap should be walkable.")
+	assert.True(t, grid.Walkable(1, 0), "Touching an edge should not block.")
+	assert.False(t, grid.Walkable(-1, 0), "Off the grid is not walkable.")
+
+	// Diagonal walls block the tile they sit in.
+	grid = NewNavGrid(2, 2, pixel.V(32, 32))
+	grid.BlockShape(&PolygonShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(32, 0), pixel.V(0, 32)}})
+	assert.False(t, grid.Walkable(0, 0), "The diagonal wall should be blocked.")
+	assert.True(t, grid.Walkable(1, 0), "Beside the wall should be walkable.")
+	assert.True(t, grid.Walkable(1, 1), "Beside the wall should be walkable.")
+}
+
+func TestFindPath(t *testing.T) {
+	grid := testGrid()
+	from := pixel.V(5, 5)
+	to := pixel.V(45, 5)
+
+	// Around the wall in straight steps.
+	path, err := grid.FindPath(from, to, PathOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 12, len(path), "We should go up, over and back down.")
+	assert.Equal(t, to, path[len(path)-1], "We should finish on the target.")
+
+	// Diagonals cut the trip down.
+	path, err = grid.FindPath(from, to, PathOptions{Diagonal: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(path), "Diagonal steps should shorten the path.")
+
+	// Smoothing keeps only the turning points.
+	path, err = grid.FindPath(from, to, PathOptions{Diagonal: true, Smooth: true})
+	assert.NoError(t, err)
+	assert.True(t, len(path) < 10, "Smoothing should remove waypoints.")
+	for i := range path {
+		start := from
+		if i > 0 {
+			start = path[i-1]
+		}
+		assert.True(t, grid.LineOfSight(start, path[i]), "Every leg should be clear.")
+	}
+
+	// Nowhere to go.
+	grid.Block(pixel.R(20, 40, 30, 50))
+	_, err = grid.FindPath(from, to, PathOptions{Diagonal: true})
+	assert.Equal(t, ErrUnreachable, err, "A sealed wall should be unreachable.")
+}
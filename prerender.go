@@ -0,0 +1,163 @@
+package gamesys
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// PreRender will draw each tile layer into Img, a picture covering the
+// whole map, the way maps were drawn before the tile renderer. Views then
+// show their part of each picture rather than drawing tiles, a single draw
+// for each layer wherever the camera is, at the cost of holding every layer
+// in memory. That suits small maps, while large and infinite maps are best
+// left to the tile renderer. Animated tiles are drawn again on their own as
+// they change frame.
+func (m *Map) PreRender() error {
+	err := m.Tiles.LoadTilesets()
+	if err != nil {
+		return err
+	}
+
+	bounds := pixel.R(0, 0, math.Ceil(m.Size.X), math.Ceil(m.Size.Y))
+	m.Img = make([]*pixel.PictureData, len(m.Src.Layers))
+	m.imgVersions = make([]int, len(m.Src.Layers))
+	m.imgAnimated = make([]*animatedTile, 0)
+	for i, l := range m.Src.Layers {
+		m.Img[i] = pixel.MakePictureData(bounds)
+		m.drawImgArea(i, bounds)
+
+		m.eachTile(i, func(column int, row int, tile *tiled.LayerTile) {
+			if animation, ok := m.Tiles.animations[tile.Tileset][tile.ID]; ok {
+				m.imgAnimated = append(m.imgAnimated, &animatedTile{tile: tile, layer: l, index: i, column: column, row: row, animation: animation})
+			}
+		})
+	}
+
+	m.imgFrames = make([]uint32, len(m.imgAnimated))
+	for i, a := range m.imgAnimated {
+		m.imgFrames[i] = a.animation.frameAt(m.Tiles.Time)
+	}
+	return nil
+}
+
+// animateImg will draw the animated tiles that have moved on to another
+// frame into Img again, at the time of the tile renderer. Only the area of
+// each changed tile is cleared and drawn, tiles it overlaps included.
+func (m *Map) animateImg() {
+	changed := make(map[int]bool)
+	for i, a := range m.imgAnimated {
+		frame := a.animation.frameAt(m.Tiles.Time)
+		if frame == m.imgFrames[i] {
+			continue
+		}
+
+		// Clear what both frames cover, then draw whatever is there now.
+		area := m.imgTileArea(a.layer, a.column, a.row, a.tile, m.imgFrames[i])
+		area = area.Union(m.imgTileArea(a.layer, a.column, a.row, a.tile, frame)).Intersect(m.Img[a.index].Rect)
+		m.imgFrames[i] = frame
+		if area.Area() == 0 {
+			continue
+		}
+
+		img := m.Img[a.index]
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				img.Pix[img.Index(pixel.V(x, y))] = color.RGBA{}
+			}
+		}
+		m.drawImgArea(a.index, area)
+		changed[a.index] = true
+	}
+
+	for index := range changed {
+		m.imgVersions[index]++
+	}
+}
+
+// drawImgArea will draw the tiles of the layer reaching into the area into
+// its picture, keeping within the area.
+func (m *Map) drawImgArea(layer int, area pixel.Rect) {
+	first, last, ok := m.Tiles.tileBounds(area, layer)
+	if !ok {
+		return
+	}
+
+	l := m.Src.Layers[layer]
+	for row := first[1]; row <= last[1]; row++ {
+		for _, column := range m.Tiles.rowOrder(first[0], last[0]+1) {
+			tile := m.Tile(layer, column, row)
+			if tile.IsNil() {
+				continue
+			}
+
+			id := tile.ID
+			if animation, ok := m.Tiles.animations[tile.Tileset][tile.ID]; ok {
+				id = animation.frameAt(m.Tiles.Time)
+			}
+			m.drawImgTile(m.Img[layer], area, l, column, row, tile, id)
+		}
+	}
+}
+
+// drawImgTile will draw the tile showing the given tile id into the picture,
+// placed just as the tile renderer would, keeping within the area. Tileset
+// images are read straight from their picture data.
+func (m *Map) drawImgTile(img *pixel.PictureData, area pixel.Rect, l *tiled.Layer, column int, row int, tile *tiled.LayerTile, id uint32) {
+	sprite := m.Tiles.sprite(tile.Tileset, id)
+	if sprite == nil {
+		return
+	}
+	pic, ok := sprite.Picture().(*pixel.PictureData)
+	if !ok {
+		return
+	}
+
+	frame := sprite.Frame()
+	matrix := m.Tiles.tileMatrix(l, column, row, tile, frame.Size())
+	area = m.imgTileArea(l, column, row, tile, id).Intersect(area).Intersect(img.Rect)
+
+	// Each pixel looks back through the tile matrix to the tileset image.
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			at := matrix.Unproject(pixel.V(x+0.5, y+0.5)).Add(frame.Center())
+			if at.X < frame.Min.X || at.X >= frame.Max.X || at.Y < frame.Min.Y || at.Y >= frame.Max.Y {
+				continue
+			}
+			i := img.Index(pixel.V(x, y))
+			img.Pix[i] = blendOver(pic.Pix[pic.Index(at)], img.Pix[i])
+		}
+	}
+}
+
+// imgTileArea will return the whole pixels the tile covers when showing the
+// given tile id.
+func (m *Map) imgTileArea(l *tiled.Layer, column int, row int, tile *tiled.LayerTile, id uint32) pixel.Rect {
+	sprite := m.Tiles.sprite(tile.Tileset, id)
+	if sprite == nil {
+		return pixel.Rect{}
+	}
+
+	size := sprite.Frame().Size()
+	matrix := m.Tiles.tileMatrix(l, column, row, tile, size)
+	corners := pixel.Rect{Min: size.Scaled(-0.5), Max: size.Scaled(0.5)}.Vertices()
+	points := make([]pixel.Vec, len(corners))
+	for i, c := range corners {
+		points[i] = matrix.Project(c)
+	}
+
+	area := pointBounds(points)
+	return pixel.Rect{Min: area.Min.Map(math.Floor), Max: area.Max.Map(math.Ceil)}
+}
+
+// blendOver will draw the color over the one below it, both having their
+// alpha premultiplied.
+func blendOver(src color.RGBA, dst color.RGBA) color.RGBA {
+	keep := 255 - uint32(src.A)
+	mix := func(s uint8, d uint8) uint8 {
+		return s + uint8((uint32(d)*keep+127)/255)
+	}
+	return color.RGBA{mix(src.R, dst.R), mix(src.G, dst.G), mix(src.B, dst.B), mix(src.A, dst.A)}
+}
@@ -0,0 +1,96 @@
+package gamesys
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/stretchr/testify/assert"
+)
+
+// paintTiles will swap the tileset image of the map for one with each of
+// the given tiles filled with a color, so we can see what is drawn where.
+func paintTiles(m *Map, colors map[uint32]color.RGBA) {
+	ts := m.Src.Tilesets[0]
+	pic := pixel.MakePictureData(pixel.R(0, 0, float64(ts.Image.Width), float64(ts.Image.Height)))
+	for id, c := range colors {
+		rect := ts.GetTileRect(id)
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				pic.Pix[(ts.Image.Height-1-y)*pic.Stride+x] = c
+			}
+		}
+	}
+	m.Tiles.pictures[ts.GetFileFullPath(ts.Image.Source)] = pic
+}
+
+func TestMapPreRender(t *testing.T) {
+	m, err := NewMap("test_assets/maps/animated.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	red, blue, green := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{0, 255, 0, 255}
+	paintTiles(m, map[uint32]color.RGBA{593: red, 594: blue, 0: green})
+
+	assert.NoError(t, m.PreRender())
+	if !assert.Len(t, m.Img, 1, "Each layer gets a picture.") {
+		return
+	}
+	img := m.Img[0]
+	at := func(x float64, y float64) color.RGBA {
+		return img.Pix[img.Index(pixel.V(x, y))]
+	}
+	assert.Equal(t, pixel.R(0, 0, 64, 64), img.Rect, "The picture covers the map.")
+	assert.Equal(t, red, at(10, 50), "The top left tile starts on its first frame.")
+	assert.Equal(t, green, at(40, 50))
+	assert.Equal(t, red, at(40, 10))
+
+	// Views show the pictures rather than drawing tiles.
+	scene := &Scene{Engine: testEngine, MapData: m}
+	view := &View{Scene: scene, Engine: testEngine, Rendered: pixelgl.NewCanvas(pixel.R(0, 0, 32, 32)), Camera: pixel.R(0, 0, 32, 32)}
+	assert.NoError(t, view.UseMap())
+	assert.Len(t, view.Output, 1)
+	assert.Equal(t, img, view.Src)
+	sprite := view.Output[0]
+	view.drawMap(false)
+	assert.True(t, sprite == view.Output[0], "Unchanged pictures keep their sprite.")
+	assert.Equal(t, view.Camera, sprite.Frame(), "The camera picks the part shown.")
+
+	// Only the animated tiles are drawn again.
+	m.Tiles.Time = 0.15
+	m.animateImg()
+	assert.Equal(t, blue, at(10, 50), "Animated tiles move on to their next frame.")
+	assert.Equal(t, blue, at(40, 10))
+	assert.Equal(t, green, at(40, 50), "Other tiles are left alone.")
+	assert.Equal(t, 1, m.imgVersions[0])
+	view.drawMap(false)
+	assert.False(t, sprite == view.Output[0], "Changed pictures get a new sprite.")
+
+	m.animateImg()
+	assert.Equal(t, 1, m.imgVersions[0], "Nothing changes until the next frame.")
+}
+
+func TestMapPreRenderFlips(t *testing.T) {
+	m, err := NewMap("test_assets/maps/animated.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Half of the tile is red, so flips move it about.
+	ts := m.Src.Tilesets[0]
+	paintTiles(m, map[uint32]color.RGBA{0: {0, 255, 0, 255}})
+	pic := m.Tiles.pictures[ts.GetFileFullPath(ts.Image.Source)].(*pixel.PictureData)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 16; x++ {
+			pic.Pix[(ts.Image.Height-1-y)*pic.Stride+x] = color.RGBA{255, 0, 0, 255}
+		}
+	}
+	m.Src.Layers[0].Tiles[1].HorizontalFlip = true
+
+	assert.NoError(t, m.PreRender())
+	img := m.Img[0]
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.Pix[img.Index(pixel.V(4, 10))], "Unflipped tiles keep their left half.")
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, img.Pix[img.Index(pixel.V(40, 50))], "Flipped tiles swap sides.")
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.Pix[img.Index(pixel.V(60, 50))])
+}
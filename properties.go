@@ -0,0 +1,272 @@
+package gamesys
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// Properties is a collection of custom values, which will be a string,
+// int, float64 or bool.
+type Properties map[string]interface{}
+
+// ParseProperty will convert a string value into the given type, being
+// string, int, float or bool. Anything else is left as a string, and bad
+// numbers are zero.
+func ParseProperty(kind string, value string) interface{} {
+	switch kind {
+	case "int":
+		parsed, _ := strconv.Atoi(value)
+		return parsed
+	case "float":
+		parsed, _ := strconv.ParseFloat(value, 64)
+		return parsed
+	case "bool":
+		parsed, _ := strconv.ParseBool(value)
+		return parsed
+	}
+	return value
+}
+
+// PropertiesFromTiled will convert Tiled custom properties, keeping their
+// types.
+func PropertiesFromTiled(source tiled.Properties) Properties {
+	properties := make(Properties)
+	for _, p := range source {
+		properties[p.Name] = ParseProperty(p.Type, p.Value)
+	}
+	return properties
+}
+
+// Has will indicate if the property is set.
+func (p Properties) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// String will return the property as a string, empty if not set.
+func (p Properties) String(name string) string {
+	value, ok := p[name]
+	if !ok {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// Int will return the property as an int, 0 if not set or not a number.
+func (p Properties) Int(name string) int {
+	switch value := propertyNumber(p[name]).(type) {
+	case float64:
+		return int(value)
+	case string:
+		parsed, _ := strconv.Atoi(value)
+		return parsed
+	}
+	return 0
+}
+
+// Float will return the property as a float64, 0 if not set or not a
+// number.
+func (p Properties) Float(name string) float64 {
+	value, _ := propertyFloat(p[name])
+	return value
+}
+
+// Bool will return the property as a bool, false if not set.
+func (p Properties) Bool(name string) bool {
+	value, _ := propertyBool(p[name])
+	return value
+}
+
+// Equals will indicate if the property is set to the value. Numbers match
+// whatever their Go type, and strings match typed values when they read as
+// the same value, so "5" matches 5 and "true" matches true.
+func (p Properties) Equals(name string, value interface{}) bool {
+	stored, ok := p[name]
+	if !ok {
+		return false
+	}
+	other := Properties{name: value}
+
+	// Compare as the typed side, when there is one.
+	_, storedFloat := propertyNumber(stored).(float64)
+	_, valueFloat := propertyNumber(value).(float64)
+	_, storedBool := stored.(bool)
+	_, valueBool := value.(bool)
+	switch {
+	case storedFloat || valueFloat:
+		_, ok := propertyFloat(stored)
+		_, otherOk := propertyFloat(value)
+		return ok && otherOk && p.Float(name) == other.Float(name)
+	case storedBool || valueBool:
+		_, ok := propertyBool(stored)
+		_, otherOk := propertyBool(value)
+		return ok && otherOk && p.Bool(name) == other.Bool(name)
+	}
+	return p.String(name) == other.String(name)
+}
+
+// propertyNumber will convert any Go number to a float64, leaving other
+// values alone.
+func propertyNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+// propertyFloat will read the value as a float64, indicating if it is a
+// number or a string holding one.
+func propertyFloat(value interface{}) (float64, bool) {
+	switch v := propertyNumber(value).(type) {
+	case float64:
+		return v, true
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+// propertyBool will read the value as a bool, indicating if it is a bool or
+// a string holding one.
+func propertyBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		return parsed, err == nil
+	}
+	return false, false
+}
+
+// SetProperty will set a custom property on the actor.
+func (a *Actor) SetProperty(name string, value interface{}) {
+	if a.Properties == nil {
+		a.Properties = make(Properties)
+	}
+	a.Properties[name] = value
+}
+
+// Tag will add the tags to the actor, skipping any it already has.
+func (a *Actor) Tag(tags ...string) {
+	for _, t := range tags {
+		if !a.HasTag(t) {
+			a.Tags = append(a.Tags, t)
+		}
+	}
+}
+
+// Untag will remove the tags from the actor.
+func (a *Actor) Untag(tags ...string) {
+	newTags := make([]string, 0)
+	for _, t := range a.Tags {
+		keep := true
+		for _, remove := range tags {
+			if t == remove {
+				keep = false
+			}
+		}
+		if keep {
+			newTags = append(newTags, t)
+		}
+	}
+	a.Tags = newTags
+}
+
+// HasTag will indicate if the actor has the tag.
+func (a *Actor) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags will split a comma separated list of tags, such as the Tiled
+// tags property.
+func ParseTags(list string) []string {
+	tags := make([]string, 0)
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// ActorsWithTag will return all of the engine actors with the tag.
+func (e *Engine) ActorsWithTag(tag string) []*Actor {
+	return filterActors(e.Actors, func(a *Actor) bool {
+		return a.HasTag(tag)
+	})
+}
+
+// ActorsWithProperty will return all of the engine actors with the
+// property set to the value, as compared by Properties.Equals. A nil value
+// finds any actor with the property.
+func (e *Engine) ActorsWithProperty(name string, value interface{}) []*Actor {
+	return filterActors(e.Actors, func(a *Actor) bool {
+		if value == nil {
+			return a.Properties.Has(name)
+		}
+		return a.Properties.Equals(name, value)
+	})
+}
+
+// ActorsInRect will return the scene actors whose clip overlaps the area.
+func (s *Scene) ActorsInRect(area pixel.Rect) []*Actor {
+	return s.nearbyActors(area, func(a *Actor) bool {
+		return a.Clip.Intersects(area)
+	})
+}
+
+// ActorsInRadius will return the scene actors positioned within the radius
+// of the point.
+func (s *Scene) ActorsInRadius(point pixel.Vec, radius float64) []*Actor {
+	area := pixel.R(point.X-radius, point.Y-radius, point.X+radius, point.Y+radius)
+	return s.nearbyActors(area, func(a *Actor) bool {
+		return math.Hypot(a.Position.X-point.X, a.Position.Y-point.Y) <= radius
+	})
+}
+
+// filterActors will return the actors that pass the test, ordered by id so
+// results are always the same.
+func filterActors(actors map[string]*Actor, test func(*Actor) bool) []*Actor {
+	ids := make([]string, 0)
+	for id, a := range actors {
+		if a != nil && test(a) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	found := make([]*Actor, len(ids))
+	for i, id := range ids {
+		found[i] = actors[id]
+	}
+	return found
+}
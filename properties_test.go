@@ -0,0 +1,78 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropertiesFromTiled(t *testing.T) {
+	properties := PropertiesFromTiled(tiled.Properties{
+		{Name: "name", Value: "Bob"},
+		{Name: "gold", Type: "int", Value: "25"},
+		{Name: "weight", Type: "float", Value: "1.5"},
+		{Name: "angry", Type: "bool", Value: "true"},
+	})
+
+	assert.Equal(t, "Bob", properties.String("name"), "Strings should be kept.")
+	assert.Equal(t, 25, properties["gold"], "Ints should be typed.")
+	assert.Equal(t, 1.5, properties.Float("weight"), "Floats should be typed.")
+	assert.True(t, properties.Bool("angry"), "Bools should be typed.")
+
+	// Conversions between types.
+	assert.Equal(t, "25", properties.String("gold"), "Ints should read as strings.")
+	assert.Equal(t, 25.0, properties.Float("gold"), "Ints should read as floats.")
+	assert.Equal(t, 0, properties.Int("missing"), "Missing should be zero.")
+	assert.False(t, properties.Has("missing"), "Missing should not be there.")
+}
+
+func TestActorTags(t *testing.T) {
+	actor := &Actor{}
+	actor.Tag(ParseTags("enemy, goblin,,enemy")...)
+	assert.Equal(t, []string{"enemy", "goblin"}, actor.Tags, "Tags should not repeat.")
+
+	actor.Untag("enemy")
+	assert.False(t, actor.HasTag("enemy"), "Enemy tag should be removed.")
+	assert.True(t, actor.HasTag("goblin"), "Goblin tag should remain.")
+}
+
+func TestActorQueries(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	near := &Actor{Position: pixel.V(10, 10), Clip: pixel.R(0, 0, 20, 20)}
+	far := &Actor{Position: pixel.V(100, 100), Clip: pixel.R(90, 90, 110, 110)}
+	scene.Actors["near"] = near
+	scene.Actors["far"] = far
+
+	assert.Equal(t, []*Actor{near}, scene.ActorsInRect(pixel.R(15, 15, 50, 50)), "Only near overlaps.")
+	assert.Equal(t, []*Actor{far, near}, scene.ActorsInRadius(pixel.V(50, 50), 75), "Both are in range, ordered by id.")
+
+	// Engine wide queries.
+	near.Tag("query")
+	near.SetProperty("gold", 5)
+	testEngine.AddActor("querynear", near)
+	defer delete(testEngine.Actors, "querynear")
+
+	assert.Equal(t, []*Actor{near}, testEngine.ActorsWithTag("query"), "We should find our tagged actor.")
+	assert.Equal(t, []*Actor{near}, testEngine.ActorsWithProperty("gold", 5), "We should find by value.")
+	assert.Empty(t, testEngine.ActorsWithProperty("gold", 6), "Other values should not match.")
+	assert.Equal(t, []*Actor{near}, testEngine.ActorsWithProperty("gold", 5.0), "Numbers should match whatever their type.")
+	assert.Equal(t, []*Actor{near}, testEngine.ActorsWithProperty("gold", "5"), "Strings should match typed values.")
+}
+
+func TestPropertiesEquals(t *testing.T) {
+	properties := Properties{"gold": 5, "weight": 1.5, "angry": true, "name": "Bob", "count": "12", "mood": "sulky"}
+
+	assert.True(t, properties.Equals("gold", 5.0))
+	assert.True(t, properties.Equals("gold", int64(5)))
+	assert.True(t, properties.Equals("weight", "1.5"))
+	assert.True(t, properties.Equals("angry", "true"))
+	assert.True(t, properties.Equals("count", 12), "Numbers should match strings holding them.")
+	assert.True(t, properties.Equals("name", "Bob"))
+
+	assert.False(t, properties.Equals("gold", "five"), "Strings that aren't numbers never match numbers.")
+	assert.False(t, properties.Equals("mood", false), "Strings that aren't bools never match bools.")
+	assert.False(t, properties.Equals("angry", 1), "Bools are not numbers.")
+	assert.False(t, properties.Equals("missing", ""), "Missing properties never match.")
+}
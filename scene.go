@@ -11,6 +11,38 @@ import (
 	"golang.org/x/image/colornames"
 )
 
+// MovementMode is how actors are allowed to move around a scene.
+type MovementMode int
+
+const (
+	// MovementFree allows movement in any direction.
+	MovementFree MovementMode = iota
+
+	// Movement8 snaps movement to the nearest of 8 directions.
+	Movement8
+
+	// Movement4 snaps movement to the nearest of 4 directions.
+	Movement4
+
+	// MovementGrid steps actors exactly one tile at a time, in 4
+	// directions, like classic RPGs.
+	MovementGrid
+)
+
+// ParseMovementMode will return the movement mode by name, being one of
+// free, 8way, 4way or grid. Unknown names are free movement.
+func ParseMovementMode(name string) MovementMode {
+	switch name {
+	case "8way":
+		return Movement8
+	case "4way":
+		return Movement4
+	case "grid":
+		return MovementGrid
+	}
+	return MovementFree
+}
+
 // Scene holds the information for a combination of views
 // and actors. A view should be able to have multiple actors
 // and multiple outputs.
@@ -21,9 +53,38 @@ type Scene struct {
 	// basespeed is the speed that this scene will run at.
 	Basespeed float64 `xml:"basespeed,attr"`
 
+	// Skin is how far actor clips are shrunk on each side when checking for
+	// collision, so actors can brush past walls without catching. The
+	// configuration uses DefaultSkin when no skin is given.
+	Skin float64 `xml:"skin,attr"`
+
+	// SolidLayer is the name of a map tile layer where every tile is solid.
+	SolidLayer string `xml:"solidlayer,attr"`
+
+	// Movement is how actors are allowed to move on this scene.
+	Movement MovementMode
+
 	// Background is the background colour to clear this screen to.
 	Background color.RGBA
 
+	// Music is the music track of the scene, announced with a "music" event
+	// when the scene is entered.
+	Music string
+
+	// Ambient is the ambient light colour the map views are tinted by, no
+	// tint being used when left empty.
+	Ambient color.RGBA
+
+	// OnEnter is the script file run when the scene is entered.
+	OnEnter string
+
+	// Encounters are the things that can be run into on the scene.
+	Encounters EncounterTable
+
+	// CameraBounds is the area the map view cameras are kept within, the
+	// whole map when empty.
+	CameraBounds pixel.Rect
+
 	// Rendered is the canvas we draw to before flipping to screen.
 	Rendered *pixelgl.Canvas
 
@@ -42,14 +103,31 @@ type Scene struct {
 	// Control is the collection of handlers specific to the scene.
 	Control *Controller
 
+	// Context is the input context pushed while this scene is active. The
+	// scene handlers should be added under this context.
+	Context string
+
 	// Engine is the engine this scene belongs to.
 	Engine *Engine
+
+	// navGrid is the navigation grid built from the map, used for paths.
+	navGrid *NavGrid
+
+	// actorGrid is the spatial index of our actors, along with the id each
+	// actor is used under and how many actors we had when last indexed.
+	actorGrid  *SpatialGrid
+	actorIDs   map[*Actor]string
+	actorCount int
+
+	// standingOn is the warp the focused actor is standing on, so it only
+	// warps when stepping onto it.
+	standingOn *Warp
 }
 
 // NewView will create a new view and attach it to the scene.
 func (s *Scene) NewView(id string, position pixel.Vec, camera pixel.Rect, bgcolor string) {
 	// A new view with some of our fields.
-	newView := &View{Visible: false, Position: position, Camera: camera, Scene: s, Engine: s.Engine}
+	newView := &View{Visible: false, Position: position, Camera: camera, YSort: true, Scene: s, Engine: s.Engine}
 
 	// The canvas we prepare and flip to screen.
 	newView.Rendered = pixelgl.NewCanvas(newView.Camera)
@@ -80,10 +158,10 @@ func (s *Scene) GetView(id string) (*View, error) {
 // RemoveView will destroy the view from the scene, also maintaining the vieworder.
 func (s *Scene) RemoveView(id string) {
 	// Loop through current view order, omitting the one matching id
-	newViewOrder := make([]string, len(s.ViewOrder)-1)
-	for i, v := range s.ViewOrder {
+	newViewOrder := make([]string, 0)
+	for _, v := range s.ViewOrder {
 		if v != id {
-			newViewOrder[i] = v
+			newViewOrder = append(newViewOrder, v)
 		}
 	}
 	s.ViewOrder = newViewOrder
@@ -103,6 +181,7 @@ func (s *Scene) LoadMap(file string) error {
 
 	// Load our mapfile directly into our scene.
 	s.MapData, err = NewMap(file)
+	s.navGrid = nil
 
 	// If we have an error, we can't continue the loading process. We can
 	// pass along the errors we set as they are relevant.
@@ -110,49 +189,162 @@ func (s *Scene) LoadMap(file string) error {
 		return err
 	}
 
-	// Get our actors from the mapdata.
-	s.LoadActorsFromMapData()
+	// Some maps paint their walls on a layer of their own.
+	if s.SolidLayer != "" {
+		s.MapData.SolidLayer(s.SolidLayer)
+	}
 
-	// All loaded with no issues, return peacefully.
-	return nil
+	// The map properties set up the rest of the scene.
+	s.useMapSettings()
+
+	// Get our actors from the mapdata, passing along any errors.
+	return s.LoadActorsFromMapData()
 }
 
-// LoadActorsFromMapData will return an array of actors that are present in the mapdata.
-func (s *Scene) LoadActorsFromMapData() {
-	// Loop through our primary object group.
-	// TODO: Allow for all object groups.
-	for _, obj := range s.MapData.Src.ObjectGroups[0].Objects {
-		if obj.Type == "Spawn" {
-			// We need to grab our properties
-			actorID := obj.Properties.GetString("gameID")
-			collision := obj.Properties.GetBool("collide")
-			file := obj.Properties.GetString("imgfile")
-
-			// We need to reverse the Y position.
-			newY := s.MapData.Size.Y - obj.Y
-			startPos := pixel.V(obj.X, newY)
-
-			// Create actor and populate fields.
-			newActor := s.Engine.NewActor(file, startPos)
-			newActor.Visible = obj.Visible
-			newActor.Collision = collision
-
-			// Add to our engine.
-			s.Engine.AddActor(actorID, newActor)
-
-			// Use the actor on this scene.
-			s.UseActor(actorID)
+// LoadActorsFromMapData will load the actors that are present in the mapdata.
+// Spawns need an imgfile or a template, otherwise an error is returned.
+func (s *Scene) LoadActorsFromMapData() error {
+	// Attached actors are linked up once everyone has spawned.
+	parents := make(map[string]string)
+
+	// Loop through all of our spawn objects.
+	for _, obj := range s.MapData.Objects(RoleSpawn) {
+		// We need to grab our properties
+		actorID := obj.Properties.GetString("gameID")
+		if parent := obj.Properties.GetString("parent"); parent != "" {
+			parents[actorID] = parent
+		}
+
+		// Tiled positions go top down, and may be along isometric axes.
+		startPos := s.MapData.ToWorld(pixel.V(obj.X, obj.Y))
+
+		// Templates take everything else as overrides, keeping their types.
+		if template := obj.Properties.GetString("template"); template != "" {
+			overrides := PropertiesFromTiled(obj.Properties)
+			delete(overrides, "template")
+			delete(overrides, "gameID")
+			overrides["visible"] = obj.Visible
+			_, err = s.Engine.SpawnActor(s, actorID, template, startPos, overrides)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		collision := obj.Properties.GetBool("collide")
+		trigger := obj.Properties.GetBool("trigger")
+		file := obj.Properties.GetString("imgfile")
+		animations := obj.Properties.GetString("animations")
+
+		// We can't create an actor without something to show.
+		if file == "" {
+			return errors.New("loadactors: spawn " + obj.Name + " has no imgfile or template")
+		}
+
+		// Create actor and populate fields.
+		newActor := s.Engine.NewActor(file, startPos)
+		newActor.Visible = obj.Visible
+		newActor.Collision = collision
+		newActor.Trigger = trigger
+
+		// Keep all of our properties for game code, tags split out.
+		newActor.Properties = PropertiesFromTiled(obj.Properties)
+		newActor.Tag(ParseTags(obj.Properties.GetString("tags"))...)
+
+		newActor.Layer = obj.Properties.GetInt("layer")
+		newActor.Radius = obj.Properties.GetFloat("radius")
+
+		// Collision layers are optional, keeping our defaults otherwise.
+		if layer := obj.Properties.GetInt("collisionLayer"); layer != 0 {
+			newActor.CollisionLayer = uint32(layer)
+		}
+		if mask := obj.Properties.GetInt("collisionMask"); mask != 0 {
+			newActor.CollisionMask = uint32(mask)
+		}
+
+		// Animations are optional, but a broken file is worth knowing.
+		if animations != "" {
+			err = s.Engine.LoadActorAnimations(newActor, animations)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Behaviours read their options from the object properties.
+		if kind := obj.Properties.GetString("behaviour"); kind != "" {
+			options := make(map[string]string)
+			for _, p := range obj.Properties {
+				options[p.Name] = p.Value
+			}
+			newActor.Behaviour, err = s.NewBehaviour(newActor, kind, options)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Add to our engine.
+		s.Engine.AddActor(actorID, newActor)
+
+		// Use the actor on this scene.
+		err = s.UseActor(actorID)
+		if err != nil {
+			return err
 		}
 	}
+
+	// Attach to parents, keeping where we were placed on the map.
+	for id, parentID := range parents {
+		child, parent := s.Engine.Actors[id], s.Engine.Actors[parentID]
+		if parent == nil {
+			return errors.New("loadactorsfrommapdata: parent " + parentID + " not found")
+		}
+		err = child.Attach(parent, parent.Matrix().Unproject(child.Position))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// UseActor will use the requested actor on this scene.
-func (s *Scene) UseActor(actor string) {
-	s.Actors[actor] = s.Engine.Actors[actor]
+// UseActor will use the requested actor on this scene, emitting an "enter"
+// event with the scene in the data. Unknown actors are rejected.
+func (s *Scene) UseActor(actor string) error {
+	a, ok := s.Engine.Actors[actor]
+	if !ok {
+		return errors.New("useactor: actor " + actor + " not found")
+	}
+
+	if old, ok := s.Actors[actor]; ok && old != a {
+		s.unindexActor(old)
+	}
+	s.Actors[actor] = a
+	s.indexActor(actor, a)
+	s.Engine.Emit(&Event{Name: "enter", Actor: a, Data: map[string]interface{}{"scene": s}})
+
+	return nil
 }
 
-// MoveActor will move an actor within the scene.
+// MoveActor will move an actor within the scene. The direction is in
+// degrees, and is snapped according to the scene movement mode. The actor
+// will face the direction even when it is unable to move.
 func (s *Scene) MoveActor(actor *Actor, direction int) {
+	// Snap our direction to what the scene allows. Grid movement on maps
+	// that aren't square snaps to the tiles instead.
+	switch {
+	case s.Movement == Movement8:
+		direction = SnapDirection(direction, 8)
+	case s.Movement == Movement4, s.Movement == MovementGrid && !s.tileSteps():
+		direction = SnapDirection(direction, 4)
+	}
+	actor.Face(direction)
+
+	// Grid movement is handled one whole tile at a time.
+	if s.Movement == MovementGrid && s.MapData != nil {
+		s.StepActor(actor, direction)
+		return
+	}
+
 	// Calculate our base movement speed.
 	speed := s.Basespeed * s.Engine.Dt
 
@@ -164,19 +356,18 @@ func (s *Scene) MoveActor(actor *Actor, direction int) {
 	movement = movement.Scaled(speed)
 	move := false
 
+	// Collision may cut our movement short, sliding along what we hit.
+	movement = s.ResolveMovement(actor, movement)
+
 	// Find our new position.
 	newPos := actor.Position.Add(movement)
 	newClip := actor.Clip.Moved(movement)
 
 	if actor.Collision {
-		if s.MapData != nil {
-			if s.CollisionFree(newClip) {
-				// All is good to move safely.
-				move = true
-			}
-		} else {
-			// No map, find proper view move unless focus and out of range
-			move = true
+		move = true
+
+		// No map, find proper view move unless focus and out of range
+		if s.MapData == nil {
 			for _, v := range s.Views {
 				if v.Focus == actor {
 					if !v.CameraContains(newClip) {
@@ -192,11 +383,86 @@ func (s *Scene) MoveActor(actor *Actor, direction int) {
 	// Now to do what we gotta do.
 	if move == true {
 		actor.MoveTo(newPos)
+		actor.moved(movement)
+	}
+}
+
+// StepActor will send the actor one tile over in the given direction,
+// lining it up with the centre of the tile. Steps are ignored while the
+// actor is still travelling, or if the tile is blocked.
+func (s *Scene) StepActor(actor *Actor, direction int) {
+	if actor.Destinations != nil {
+		return
 	}
+
+	// Find the centre of the next tile over.
+	var target pixel.Vec
+	if s.tileSteps() {
+		target = s.neighbourToward(actor.Position, direction)
+	} else {
+		tile := s.TileSize()
+		step := pixel.Unit(float64(direction) * DegRad)
+		column := math.Floor(actor.Position.X/tile.X) + math.Round(step.X)
+		row := math.Floor(actor.Position.Y/tile.Y) + math.Round(step.Y)
+		target = pixel.V((column+0.5)*tile.X, (row+0.5)*tile.Y)
+	}
+
+	if actor.Collision {
+		clip := actor.Clip.Moved(actor.Position.To(target))
+		if !s.ActorCollisionFree(actor, clip) || !s.CollisionFree(s.Skinned(clip)) {
+			return
+		}
+	}
+
+	actor.Destinations = []pixel.Vec{target}
+}
+
+// tileSteps will indicate if grid steps follow the map tiles rather than
+// square tiles, as on isometric, staggered and hexagonal maps.
+func (s *Scene) tileSteps() bool {
+	if s.MapData == nil {
+		return false
+	}
+	o := s.MapData.Grid.Orientation
+	return o != "" && o != OrientationOrthogonal
 }
 
+// neighbourToward will return the middle of the tile next to the position
+// that is closest to the direction. Ties go to the tile anticlockwise, so
+// each of the 4 directions reaches a different diamond.
+func (s *Scene) neighbourToward(position pixel.Vec, direction int) pixel.Vec {
+	m := s.MapData
+	from := m.TileToWorld(m.WorldToTile(position))
+	want := float64(direction) + 1e-6
+
+	var target pixel.Vec
+	best := math.Inf(1)
+	for _, n := range m.Grid.Neighbours(m.WorldToTile(position)) {
+		center := m.TileToWorld(n[0], n[1])
+		turn := math.Abs(math.Mod(from.To(center).Angle()*RadDeg-want+540, 360) - 180)
+		if turn < best {
+			best, target = turn, center
+		}
+	}
+	return target
+}
+
+// TileSize will return the size of the map tiles on this scene. Without a
+// map, we work with 32 pixel tiles.
+func (s *Scene) TileSize() pixel.Vec {
+	if s.MapData == nil {
+		return pixel.V(32, 32)
+	}
+	return pixel.V(float64(s.MapData.Src.TileWidth), float64(s.MapData.Src.TileHeight))
+}
+
+// destinationStall is the share of their travel actors need to close on a
+// destination each cycle, or they give up on it.
+const destinationStall = 0.1
+
 // ProcessActorDestinations will move the relevent actors towards
-// their respective destinations
+// their respective destinations. Colliding actors slide around anything in
+// their way, and give up on a destination they can't make progress towards.
 func (s *Scene) ProcessActorDestinations() {
 	for _, a := range s.Actors {
 		if a.Destinations != nil {
@@ -208,35 +474,35 @@ func (s *Scene) ProcessActorDestinations() {
 			distance := math.Hypot(motion.X, motion.Y)
 			// travel is how far we should travel, given game speed
 			travel := s.Engine.Dt * (s.Basespeed * a.Speed)
-			// Do we travel all the way or not?
-			if travel >= distance {
+
+			// Don't overshoot our destination.
+			if travel < distance {
+				motion = motion.Scaled(travel / distance)
+			}
+
+			// See how far we actually get.
+			movement := s.ResolveMovement(a, motion)
+			a.moved(movement)
+			a.Move(movement)
+
+			if movement == motion && travel >= distance {
 				// Here we reach the destination
 				a.MoveTo(dest)
-				if len(a.Destinations) > 1 {
-					a.Destinations = a.Destinations[1:]
-				} else {
-					a.Destinations = nil
-				}
-			} else {
-				// Here we calculate our finished motion position.
-				diff := distance - travel
-				ratio := diff / distance
-				newDistance := pixel.V(ratio*motion.X, ratio*motion.Y)
-				newPos := dest.Sub(newDistance)
-
-				// Move to our hopefully new position
-				a.MoveTo(newPos)
+				a.nextDestination()
+			} else if travel > 0 && distance-a.Position.To(dest).Len() < travel*destinationStall {
+				// Barely getting closer, so move along to the next one.
+				a.nextDestination()
 			}
 		}
 	}
 }
 
 // CollisionFree will indicate the space is free of collisions. It tests
-// against the collision objects that are found in the map file.
+// against the collision shapes that are found in the map file.
 func (s *Scene) CollisionFree(clip pixel.Rect) bool {
 	if s.MapData != nil {
-		for _, c := range s.MapData.Collision {
-			if c.Intersects(clip) {
+		for _, c := range s.MapData.CollisionNear(clip) {
+			if c.IntersectsRect(clip) {
 				return false
 			}
 		}
@@ -268,5 +534,5 @@ func (s *Scene) Draw() {
 
 	// Now to put the canvas to the screen
 	s.Engine.win.Clear(s.Background)
-	s.Rendered.Draw(s.Engine.win, pixel.IM.Moved(s.Rendered.Bounds().Center()))
+	s.Rendered.DrawColorMask(s.Engine.win, pixel.IM.Moved(s.Rendered.Bounds().Center()), s.Engine.Transition.Mask())
 }
@@ -0,0 +1,330 @@
+package gamesys
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Shape is an area that collides, such as a wall on the map. Touching the
+// edge of a shape doesn't count as intersecting it, so actors can slide
+// along walls.
+type Shape interface {
+	// Bounds is the box covering the whole shape.
+	Bounds() pixel.Rect
+
+	// IntersectsRect will indicate if the shape overlaps the rect.
+	IntersectsRect(r pixel.Rect) bool
+
+	// IntersectsCircle will indicate if the shape overlaps the circle.
+	IntersectsCircle(c pixel.Circle) bool
+}
+
+// RectShape is an axis aligned box.
+type RectShape struct {
+	Rect pixel.Rect
+}
+
+// Bounds will return the rect itself.
+func (s *RectShape) Bounds() pixel.Rect {
+	return s.Rect
+}
+
+// IntersectsRect will indicate if the rects overlap. Unlike pixel, rects
+// that only share an edge don't.
+func (s *RectShape) IntersectsRect(r pixel.Rect) bool {
+	return s.Rect.Min.X < r.Max.X && s.Rect.Max.X > r.Min.X && s.Rect.Min.Y < r.Max.Y && s.Rect.Max.Y > r.Min.Y
+}
+
+// IntersectsCircle will indicate if the circle reaches inside the rect.
+func (s *RectShape) IntersectsCircle(c pixel.Circle) bool {
+	closest := pixel.V(
+		math.Max(s.Rect.Min.X, math.Min(c.Center.X, s.Rect.Max.X)),
+		math.Max(s.Rect.Min.Y, math.Min(c.Center.Y, s.Rect.Max.Y)),
+	)
+	return closest.To(c.Center).Len() < c.Radius
+}
+
+// PolygonShape is a closed shape, which may be concave.
+type PolygonShape struct {
+	Points []pixel.Vec
+}
+
+// Bounds will return the box around all of the points.
+func (s *PolygonShape) Bounds() pixel.Rect {
+	return pointBounds(s.Points)
+}
+
+// IntersectsRect will indicate if the polygon and rect overlap, either by
+// one being inside the other or by their edges crossing. Polygons sharing
+// corners and edges with the rect, such as a diagonal wall filling half a
+// tile, are caught by their middles.
+func (s *PolygonShape) IntersectsRect(r pixel.Rect) bool {
+	if !s.Bounds().Intersects(r) {
+		return false
+	}
+
+	for _, p := range s.Points {
+		if strictlyInside(r, p) {
+			return true
+		}
+	}
+	for _, e := range s.edges(true) {
+		if strictlyInside(r, e[0].Add(e[1]).Scaled(0.5)) {
+			return true
+		}
+	}
+	if centroid := s.centroid(); strictlyInside(r, centroid) && s.inside(centroid) {
+		return true
+	}
+	corners := r.Vertices()
+	for _, point := range append(corners[:], r.Center()) {
+		if s.inside(point) {
+			return true
+		}
+	}
+	return edgesCross(s.edges(true), r)
+}
+
+// inside will indicate if the point is inside the polygon, not just on an
+// edge.
+func (s *PolygonShape) inside(point pixel.Vec) bool {
+	return s.Contains(point) && !s.onEdge(point)
+}
+
+// centroid will return the average of the points.
+func (s *PolygonShape) centroid() pixel.Vec {
+	sum := pixel.ZV
+	for _, p := range s.Points {
+		sum = sum.Add(p)
+	}
+	return sum.Scaled(1 / float64(len(s.Points)))
+}
+
+// IntersectsCircle will indicate if the circle reaches inside the polygon.
+func (s *PolygonShape) IntersectsCircle(c pixel.Circle) bool {
+	if s.Contains(c.Center) {
+		return true
+	}
+	for _, e := range s.edges(true) {
+		if segmentDistance(e[0], e[1], c.Center) < c.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains will indicate if the point is inside the polygon.
+func (s *PolygonShape) Contains(point pixel.Vec) bool {
+	inside := false
+	for _, e := range s.edges(true) {
+		a, b := e[0], e[1]
+		if (a.Y > point.Y) != (b.Y > point.Y) && point.X < a.X+(point.Y-a.Y)/(b.Y-a.Y)*(b.X-a.X) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onEdge will indicate if the point lies on an edge of the polygon, where
+// it only touches.
+func (s *PolygonShape) onEdge(point pixel.Vec) bool {
+	for _, e := range s.edges(true) {
+		if segmentDistance(e[0], e[1], point) < 1e-9 {
+			return true
+		}
+	}
+	return false
+}
+
+// edges will return each edge of the points, joining the last to the first
+// when closed.
+func (s *PolygonShape) edges(closed bool) [][2]pixel.Vec {
+	return pointEdges(s.Points, closed)
+}
+
+// EllipseShape is an axis aligned ellipse, or a circle when both radii
+// match.
+type EllipseShape struct {
+	Center pixel.Vec
+
+	// Radius is the radius across and up.
+	Radius pixel.Vec
+}
+
+// NewCircleShape will create a circle.
+func NewCircleShape(center pixel.Vec, radius float64) *EllipseShape {
+	return &EllipseShape{Center: center, Radius: pixel.V(radius, radius)}
+}
+
+// Bounds will return the box around the ellipse.
+func (s *EllipseShape) Bounds() pixel.Rect {
+	return pixel.R(s.Center.X-s.Radius.X, s.Center.Y-s.Radius.Y, s.Center.X+s.Radius.X, s.Center.Y+s.Radius.Y)
+}
+
+// IntersectsRect will indicate if the ellipse and rect overlap. Squashing
+// the world so the ellipse is a circle keeps the rect a rect.
+func (s *EllipseShape) IntersectsRect(r pixel.Rect) bool {
+	squash := func(v pixel.Vec) pixel.Vec {
+		return pixel.V((v.X-s.Center.X)/s.Radius.X, (v.Y-s.Center.Y)/s.Radius.Y)
+	}
+	rect := &RectShape{Rect: pixel.Rect{Min: squash(r.Min), Max: squash(r.Max)}}
+	return rect.IntersectsCircle(pixel.C(pixel.ZV, 1))
+}
+
+// IntersectsCircle will indicate if the circle reaches inside the ellipse.
+// Ellipses that aren't circles are treated as a many sided polygon.
+func (s *EllipseShape) IntersectsCircle(c pixel.Circle) bool {
+	if s.Radius.X == s.Radius.Y {
+		return s.Center.To(c.Center).Len() < s.Radius.X+c.Radius
+	}
+	return s.polygon().IntersectsCircle(c)
+}
+
+// polygon will return a polygon closely following the ellipse.
+func (s *EllipseShape) polygon() *PolygonShape {
+	const sides = 24
+	points := make([]pixel.Vec, sides)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / sides
+		points[i] = s.Center.Add(pixel.V(math.Cos(angle)*s.Radius.X, math.Sin(angle)*s.Radius.Y))
+	}
+	return &PolygonShape{Points: points}
+}
+
+// PolylineShape is an open line of walls. One sided lines only block actors
+// on their left, walking from the first point to the last, so actors on
+// their right pass straight through. Good for ledges that can be jumped
+// down but not climbed.
+type PolylineShape struct {
+	Points []pixel.Vec
+
+	// OneSided lines only block from one side.
+	OneSided bool
+}
+
+// Bounds will return the box around all of the points.
+func (s *PolylineShape) Bounds() pixel.Rect {
+	return pointBounds(s.Points)
+}
+
+// IntersectsRect will indicate if any part of the line is inside the rect.
+func (s *PolylineShape) IntersectsRect(r pixel.Rect) bool {
+	if !boundsTouch(s.Bounds(), r) {
+		return false
+	}
+
+	for _, p := range s.Points {
+		if strictlyInside(r, p) {
+			return true
+		}
+	}
+	return edgesCross(pointEdges(s.Points, false), r)
+}
+
+// IntersectsCircle will indicate if any part of the line is inside the
+// circle.
+func (s *PolylineShape) IntersectsCircle(c pixel.Circle) bool {
+	for _, e := range pointEdges(s.Points, false) {
+		if segmentDistance(e[0], e[1], c.Center) < c.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+// Passable will indicate if something at the point can pass through the
+// line, which is only ever true for one sided lines. The side is taken from
+// the closest part of the line.
+func (s *PolylineShape) Passable(point pixel.Vec) bool {
+	if !s.OneSided {
+		return false
+	}
+
+	var closest [2]pixel.Vec
+	distance := math.Inf(1)
+	for _, e := range pointEdges(s.Points, false) {
+		if d := segmentDistance(e[0], e[1], point); d < distance {
+			closest, distance = e, d
+		}
+	}
+	return cross(closest[0].To(closest[1]), closest[0].To(point)) <= 0
+}
+
+// pointBounds will return the box around all of the points.
+func pointBounds(points []pixel.Vec) pixel.Rect {
+	if len(points) == 0 {
+		return pixel.Rect{}
+	}
+	bounds := pixel.R(points[0].X, points[0].Y, points[0].X, points[0].Y)
+	for _, p := range points {
+		bounds.Min = pixel.V(math.Min(bounds.Min.X, p.X), math.Min(bounds.Min.Y, p.Y))
+		bounds.Max = pixel.V(math.Max(bounds.Max.X, p.X), math.Max(bounds.Max.Y, p.Y))
+	}
+	return bounds
+}
+
+// boundsTouch will indicate if the boxes overlap or share an edge, as flat
+// lines have boxes with no area.
+func boundsTouch(a pixel.Rect, b pixel.Rect) bool {
+	return a.Min.X <= b.Max.X && a.Max.X >= b.Min.X && a.Min.Y <= b.Max.Y && a.Max.Y >= b.Min.Y
+}
+
+// pointEdges will return the edges between the points, joining the last to
+// the first when closed.
+func pointEdges(points []pixel.Vec, closed bool) [][2]pixel.Vec {
+	edges := make([][2]pixel.Vec, 0, len(points))
+	for i := 0; i < len(points)-1; i++ {
+		edges = append(edges, [2]pixel.Vec{points[i], points[i+1]})
+	}
+	if closed && len(points) > 2 {
+		edges = append(edges, [2]pixel.Vec{points[len(points)-1], points[0]})
+	}
+	return edges
+}
+
+// strictlyInside will indicate if the point is inside the rect, not on its
+// edge.
+func strictlyInside(r pixel.Rect, p pixel.Vec) bool {
+	return p.X > r.Min.X && p.X < r.Max.X && p.Y > r.Min.Y && p.Y < r.Max.Y
+}
+
+// edgesCross will indicate if any of the edges cross an edge of the rect.
+func edgesCross(edges [][2]pixel.Vec, r pixel.Rect) bool {
+	corners := r.Vertices()
+	for _, e := range edges {
+		for i := range corners {
+			if segmentsCross(e[0], e[1], corners[i], corners[(i+1)%4]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// segmentsCross will indicate if two segments properly cross, rather than
+// just touching.
+func segmentsCross(a pixel.Vec, b pixel.Vec, c pixel.Vec, d pixel.Vec) bool {
+	d1 := cross(a.To(b), a.To(c))
+	d2 := cross(a.To(b), a.To(d))
+	d3 := cross(c.To(d), c.To(a))
+	d4 := cross(c.To(d), c.To(b))
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+// segmentDistance will return the distance from the point to the closest
+// part of the segment.
+func segmentDistance(a pixel.Vec, b pixel.Vec, p pixel.Vec) float64 {
+	ab := a.To(b)
+	if ab.Len() == 0 {
+		return a.To(p).Len()
+	}
+	t := math.Max(0, math.Min(1, a.To(p).Dot(ab)/ab.Dot(ab)))
+	return a.Add(ab.Scaled(t)).To(p).Len()
+}
+
+// cross will return the cross product of two vectors, positive when b is to
+// the left of a.
+func cross(a pixel.Vec, b pixel.Vec) float64 {
+	return a.X*b.Y - a.Y*b.X
+}
@@ -0,0 +1,103 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRectShape(t *testing.T) {
+	rect := &RectShape{Rect: pixel.R(0, 0, 10, 10)}
+	assert.True(t, rect.IntersectsRect(pixel.R(5, 5, 15, 15)))
+	assert.False(t, rect.IntersectsRect(pixel.R(10, 0, 20, 10)), "Touching edges don't count.")
+	assert.True(t, rect.IntersectsCircle(pixel.C(pixel.V(12, 5), 3)))
+	assert.False(t, rect.IntersectsCircle(pixel.C(pixel.V(13, 13), 3)), "Corners are round for circles.")
+}
+
+func TestPolygonShape(t *testing.T) {
+	// A concave L shape.
+	poly := &PolygonShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(20, 0), pixel.V(20, 10), pixel.V(10, 10), pixel.V(10, 20), pixel.V(0, 20)}}
+	assert.True(t, poly.Contains(pixel.V(5, 15)))
+	assert.False(t, poly.Contains(pixel.V(15, 15)), "The notch is outside.")
+	assert.False(t, poly.IntersectsRect(pixel.R(12, 12, 18, 18)), "Rects in the notch are clear.")
+	assert.True(t, poly.IntersectsRect(pixel.R(2, 2, 4, 4)), "Rects inside collide.")
+	assert.True(t, poly.IntersectsRect(pixel.R(-5, -5, 25, 25)), "Rects around collide.")
+	assert.True(t, poly.IntersectsRect(pixel.R(-5, 5, 25, 8)), "Crossing rects collide.")
+	assert.True(t, poly.IntersectsCircle(pixel.C(pixel.V(15, 15), 6)))
+	assert.False(t, poly.IntersectsCircle(pixel.C(pixel.V(16, 16), 4)))
+
+	// Shapes lined up with the rect corners still fill it.
+	tile := pixel.R(0, 0, 32, 32)
+	wall := &PolygonShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(32, 0), pixel.V(0, 32)}}
+	assert.True(t, wall.IntersectsRect(tile), "A diagonal wall fills half the tile.")
+	diamond := &PolygonShape{Points: []pixel.Vec{pixel.V(16, 32), pixel.V(32, 16), pixel.V(16, 0), pixel.V(0, 16)}}
+	assert.True(t, diamond.IntersectsRect(tile), "A diamond inside the tile collides.")
+	square := &PolygonShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(32, 0), pixel.V(32, 32), pixel.V(0, 32)}}
+	assert.True(t, square.IntersectsRect(tile), "A square matching the tile collides.")
+	assert.False(t, wall.IntersectsRect(pixel.R(32, 0, 64, 32)), "Touching the tile beside doesn't count.")
+	assert.False(t, wall.IntersectsRect(pixel.R(16, 16, 48, 48)), "Touching the diagonal doesn't count.")
+}
+
+func TestEllipseShape(t *testing.T) {
+	ellipse := &EllipseShape{Center: pixel.ZV, Radius: pixel.V(20, 10)}
+	assert.True(t, ellipse.IntersectsRect(pixel.R(15, -2, 25, 2)))
+	assert.False(t, ellipse.IntersectsRect(pixel.R(15, 8, 25, 12)), "The ellipse curves away.")
+	assert.True(t, ellipse.IntersectsCircle(pixel.C(pixel.V(0, 12), 3)))
+	assert.False(t, ellipse.IntersectsCircle(pixel.C(pixel.V(0, 14), 3)))
+
+	circle := NewCircleShape(pixel.ZV, 10)
+	assert.True(t, circle.IntersectsCircle(pixel.C(pixel.V(15, 0), 6)))
+	assert.False(t, circle.IntersectsCircle(pixel.C(pixel.V(15, 0), 5)), "Touching circles don't count.")
+}
+
+func TestPolylineShape(t *testing.T) {
+	line := &PolylineShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(20, 0)}, OneSided: true}
+	assert.True(t, line.IntersectsRect(pixel.R(5, -5, 10, 5)))
+	assert.False(t, line.IntersectsRect(pixel.R(5, 0, 10, 5)), "Resting on the line doesn't count.")
+	assert.True(t, line.IntersectsCircle(pixel.C(pixel.V(10, 2), 3)))
+	assert.False(t, line.Passable(pixel.V(10, 5)), "We block from the left.")
+	assert.True(t, line.Passable(pixel.V(10, -5)), "We let things through from the right.")
+}
+
+func TestMapShapes(t *testing.T) {
+	m, err := NewMap("test_assets/maps/shapes.tmx")
+	assert.NoError(t, err)
+	if !assert.Len(t, m.Collision, 4) {
+		return
+	}
+
+	assert.IsType(t, &RectShape{}, m.Collision[0])
+	assert.Equal(t, &EllipseShape{Center: pixel.V(96, 112), Radius: pixel.V(32, 16)}, m.Collision[1])
+	assert.Equal(t, &PolygonShape{Points: []pixel.Vec{pixel.V(0, 0), pixel.V(64, 64), pixel.V(64, 0)}}, m.Collision[2])
+	assert.Equal(t, &PolylineShape{Points: []pixel.Vec{pixel.V(128, 32), pixel.V(64, 32)}, OneSided: true}, m.Collision[3])
+
+	// Walking into the slope from the left stops short of it.
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor), MapData: m}
+	hero := &Actor{Collision: true, CollisionLayer: 1, CollisionMask: CollisionAll, Clip: pixel.R(0, 40, 10, 50)}
+	movement := scene.ResolveMovement(hero, pixel.V(40, 0))
+	assert.Equal(t, 30.0, movement.X, "We should stop at the slope.")
+
+	// The ledge can be dropped down but not climbed.
+	hero.Clip = pixel.R(90, 35, 100, 45)
+	assert.Equal(t, pixel.V(0, -5), scene.ResolveMovement(hero, pixel.V(0, -5)), "We can drop down over the ledge.")
+	hero.Clip = pixel.R(90, 20, 100, 30)
+	assert.Equal(t, pixel.V(0, 2), scene.ResolveMovement(hero, pixel.V(0, 10)), "The ledge blocks us from below.")
+
+	// Circles slip past rect corners that would stop a clip.
+	hero.Clip = pixel.R(34, 87, 44, 97)
+	hero.Radius = 4
+	assert.Equal(t, pixel.V(-10, 0), scene.ResolveMovement(hero, pixel.V(-10, 0)))
+	hero.Radius = 0
+	assert.Equal(t, pixel.V(-2, 0), scene.ResolveMovement(hero, pixel.V(-10, 0)))
+}
+
+func TestActorRadiusAction(t *testing.T) {
+	hero := newTestActor("hero", pixel.ZV)
+	actionScene("radius", hero)
+	defer delete(testEngine.Scenes, "radius")
+
+	assert.NoError(t, runAction("ActorRadius", "radius", "hero", "6"))
+	assert.Equal(t, 6.0, hero.Radius, "Our radius should be set.")
+	assert.Error(t, runAction("ActorRadius", "radius", "nobody", "6"), "Unknown actors should be an error.")
+}
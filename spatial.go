@@ -0,0 +1,285 @@
+package gamesys
+
+import (
+	"math"
+	"sort"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	// DefaultCellSize is the spatial grid cell size used when there are no
+	// map tiles to size the cells from.
+	DefaultCellSize = 64.0
+)
+
+// SpatialGrid is a uniform grid that remembers which items overlap each
+// cell, so queries only look at what is near an area rather than at
+// everything. Items are anything that can be a map key, such as shapes or
+// actors, each kept with its bounds.
+type SpatialGrid struct {
+	// CellSize is the width and height of each cell.
+	CellSize float64
+
+	// cells are the items overlapping each cell, in the order they arrived.
+	cells map[gridCell][]interface{}
+
+	// bounds are the bounds of each item in the grid.
+	bounds map[interface{}]pixel.Rect
+}
+
+// gridCell is the column and row of a cell in a spatial grid.
+type gridCell struct {
+	column, row int
+}
+
+// NewSpatialGrid will create an empty grid with the given cell size.
+func NewSpatialGrid(cellSize float64) *SpatialGrid {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+	return &SpatialGrid{CellSize: cellSize, cells: make(map[gridCell][]interface{}), bounds: make(map[interface{}]pixel.Rect)}
+}
+
+// Len will return how many items are in the grid.
+func (g *SpatialGrid) Len() int {
+	return len(g.bounds)
+}
+
+// Has will indicate if the item is in the grid.
+func (g *SpatialGrid) Has(item interface{}) bool {
+	_, ok := g.bounds[item]
+	return ok
+}
+
+// Insert will add the item to every cell its bounds cover. Items already in
+// the grid are updated instead.
+func (g *SpatialGrid) Insert(item interface{}, bounds pixel.Rect) {
+	if g.Has(item) {
+		g.Update(item, bounds)
+		return
+	}
+
+	g.bounds[item] = bounds
+	g.eachCell(bounds, func(c gridCell) {
+		g.cells[c] = append(g.cells[c], item)
+	})
+}
+
+// Remove will take the item out of the grid.
+func (g *SpatialGrid) Remove(item interface{}) {
+	bounds, ok := g.bounds[item]
+	if !ok {
+		return
+	}
+
+	delete(g.bounds, item)
+	g.eachCell(bounds, func(c gridCell) {
+		items := g.cells[c]
+		for i, found := range items {
+			if found == item {
+				items = append(items[:i], items[i+1:]...)
+				break
+			}
+		}
+		if len(items) == 0 {
+			delete(g.cells, c)
+		} else {
+			g.cells[c] = items
+		}
+	})
+}
+
+// Update will move the item to its new bounds. Items that stay within the
+// same cells only have their bounds changed.
+func (g *SpatialGrid) Update(item interface{}, bounds pixel.Rect) {
+	old, ok := g.bounds[item]
+	if !ok {
+		g.Insert(item, bounds)
+		return
+	}
+
+	if g.cellRange(old) == g.cellRange(bounds) {
+		g.bounds[item] = bounds
+		return
+	}
+
+	g.Remove(item)
+	g.Insert(item, bounds)
+}
+
+// Query will return the items whose bounds overlap or touch the area, each
+// only once.
+func (g *SpatialGrid) Query(area pixel.Rect) []interface{} {
+	found := make([]interface{}, 0)
+	seen := make(map[interface{}]bool)
+
+	g.eachCell(area, func(c gridCell) {
+		for _, item := range g.cells[c] {
+			if !seen[item] && boundsTouch(g.bounds[item], area) {
+				seen[item] = true
+				found = append(found, item)
+			}
+		}
+	})
+
+	return found
+}
+
+// cellRange will return the first and last cells covered by the bounds.
+// Edges sitting on a cell line count for both cells, so touching bounds are
+// always found.
+func (g *SpatialGrid) cellRange(bounds pixel.Rect) [2]gridCell {
+	return [2]gridCell{
+		{int(math.Floor(bounds.Min.X / g.CellSize)), int(math.Floor(bounds.Min.Y / g.CellSize))},
+		{int(math.Floor(bounds.Max.X / g.CellSize)), int(math.Floor(bounds.Max.Y / g.CellSize))},
+	}
+}
+
+// eachCell will run the function for every cell covered by the bounds.
+func (g *SpatialGrid) eachCell(bounds pixel.Rect, fn func(gridCell)) {
+	r := g.cellRange(bounds.Norm())
+	for row := r[0].row; row <= r[1].row; row++ {
+		for column := r[0].column; column <= r[1].column; column++ {
+			fn(gridCell{column, row})
+		}
+	}
+}
+
+// CollisionNear will return the collision shapes whose bounds touch the
+// area. The shapes are indexed the first time they are needed, and shapes
+// added to Collision since are picked up by indexing them again.
+func (m *Map) CollisionNear(area pixel.Rect) []Shape {
+	if m.collisionGrid == nil || m.indexed != len(m.Collision) {
+		m.indexCollision()
+	}
+
+	items := m.collisionGrid.Query(area)
+	shapes := make([]Shape, len(items))
+	for i, item := range items {
+		shapes[i] = item.(Shape)
+	}
+	return shapes
+}
+
+// indexCollision will build the spatial index of our collision shapes, with
+// cells two tiles across.
+func (m *Map) indexCollision() {
+	size := DefaultCellSize
+	if m.Src != nil {
+		size = 2 * math.Max(float64(m.Src.TileWidth), float64(m.Src.TileHeight))
+	}
+
+	m.collisionGrid = NewSpatialGrid(size)
+	for _, c := range m.Collision {
+		m.collisionGrid.Insert(c, c.Bounds())
+	}
+	m.indexed = len(m.Collision)
+}
+
+// bounds will return the area the actor is indexed under, covering both its
+// clip and its position.
+func (a *Actor) bounds() pixel.Rect {
+	return a.Clip.Union(pixel.Rect{Min: a.Position, Max: a.Position})
+}
+
+// reindex will update the actor in every spatial grid it belongs to.
+func (a *Actor) reindex() {
+	for _, g := range a.grids {
+		g.Update(a, a.bounds())
+	}
+}
+
+// actorIndex will return the spatial index of the scene actors, kept up to
+// date by UseActor and RemoveActor. Actors added or removed in Actors
+// directly change the count, so are picked up by indexing them all again,
+// but actors replaced directly need a Reindex.
+func (s *Scene) actorIndex() *SpatialGrid {
+	if s.actorGrid == nil || len(s.Actors) != s.actorCount {
+		s.indexActors()
+	}
+	return s.actorGrid
+}
+
+// Reindex will build the spatial index of the scene actors again, for when
+// Actors has been changed directly rather than through UseActor and
+// RemoveActor.
+func (s *Scene) Reindex() {
+	s.indexActors()
+}
+
+// indexActors will build the spatial index of the scene actors from
+// scratch, using the same cells as the map collision.
+func (s *Scene) indexActors() {
+	if s.actorGrid != nil {
+		for a := range s.actorGrid.bounds {
+			a.(*Actor).leaveGrid(s.actorGrid)
+		}
+	}
+
+	size := DefaultCellSize
+	if s.MapData != nil && s.MapData.Src != nil {
+		size = 2 * math.Max(float64(s.MapData.Src.TileWidth), float64(s.MapData.Src.TileHeight))
+	}
+
+	s.actorGrid = NewSpatialGrid(size)
+	s.actorIDs = make(map[*Actor]string)
+	for id, a := range s.Actors {
+		if a != nil {
+			s.indexActor(id, a)
+		}
+	}
+	s.actorCount = len(s.Actors)
+}
+
+// indexActor will add the actor to the scene index under its id, and the
+// index then follows the actor as it moves.
+func (s *Scene) indexActor(id string, a *Actor) {
+	if s.actorGrid == nil {
+		return
+	}
+	if !s.actorGrid.Has(a) {
+		a.grids = append(a.grids, s.actorGrid)
+	}
+	s.actorGrid.Insert(a, a.bounds())
+	s.actorIDs[a] = id
+	s.actorCount = len(s.Actors)
+}
+
+// unindexActor will take the actor out of the scene index.
+func (s *Scene) unindexActor(a *Actor) {
+	if s.actorGrid == nil {
+		return
+	}
+	s.actorGrid.Remove(a)
+	delete(s.actorIDs, a)
+	a.leaveGrid(s.actorGrid)
+	s.actorCount = len(s.Actors)
+}
+
+// leaveGrid will stop the actor updating the grid as it moves.
+func (a *Actor) leaveGrid(grid *SpatialGrid) {
+	grids := make([]*SpatialGrid, 0)
+	for _, g := range a.grids {
+		if g != grid {
+			grids = append(grids, g)
+		}
+	}
+	a.grids = grids
+}
+
+// nearbyActors will return the scene actors whose bounds touch the area and
+// pass the test, ordered by id so results are always the same.
+func (s *Scene) nearbyActors(area pixel.Rect, test func(*Actor) bool) []*Actor {
+	found := make([]*Actor, 0)
+	for _, item := range s.actorIndex().Query(area) {
+		if a := item.(*Actor); test(a) {
+			found = append(found, a)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return s.actorIDs[found[i]] < s.actorIDs[found[j]]
+	})
+	return found
+}
@@ -0,0 +1,187 @@
+package gamesys
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpatialGrid(t *testing.T) {
+	grid := NewSpatialGrid(32)
+	grid.Insert("wall", pixel.R(0, 0, 100, 10))
+	grid.Insert("rock", pixel.R(200, 200, 210, 210))
+
+	assert.Equal(t, 2, grid.Len())
+	assert.Equal(t, []interface{}{"wall"}, grid.Query(pixel.R(90, 5, 95, 20)), "Only the wall is near.")
+	assert.Equal(t, []interface{}{"wall"}, grid.Query(pixel.R(100, 10, 120, 20)), "Touching bounds are found.")
+	assert.Empty(t, grid.Query(pixel.R(120, 120, 150, 150)), "Nothing is here.")
+
+	grid.Update("rock", pixel.R(60, 60, 70, 70))
+	assert.Equal(t, []interface{}{"rock"}, grid.Query(pixel.R(50, 50, 65, 65)), "The rock should be found where it moved to.")
+	assert.Empty(t, grid.Query(pixel.R(195, 195, 215, 215)), "The rock should have left.")
+
+	grid.Remove("wall")
+	assert.False(t, grid.Has("wall"))
+	assert.Empty(t, grid.Query(pixel.R(0, 0, 50, 50)))
+}
+
+func TestMapCollisionNear(t *testing.T) {
+	wall := &RectShape{Rect: pixel.R(0, 0, 32, 32)}
+	m := &Map{Collision: []Shape{wall}}
+	assert.Equal(t, []Shape{wall}, m.CollisionNear(pixel.R(30, 30, 40, 40)))
+
+	// Shapes added later are picked up too.
+	rock := &RectShape{Rect: pixel.R(300, 300, 332, 332)}
+	m.addCollision(rock)
+	assert.Equal(t, []Shape{rock}, m.CollisionNear(pixel.R(310, 310, 320, 320)))
+	m.Collision = append(m.Collision, NewCircleShape(pixel.V(500, 500), 10))
+	assert.Len(t, m.CollisionNear(pixel.R(480, 480, 520, 520)), 1)
+}
+
+func TestSceneActorIndex(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	hero := newTestActor("hero", pixel.V(100, 100))
+	scene.Actors["hero"] = hero
+
+	assert.Equal(t, []*Actor{hero}, scene.ActorsInRect(pixel.R(90, 90, 110, 110)))
+
+	// The index follows the actor around.
+	hero.MoveTo(pixel.V(500, 500))
+	assert.Empty(t, scene.ActorsInRect(pixel.R(90, 90, 110, 110)), "The hero has moved away.")
+	assert.Equal(t, []*Actor{hero}, scene.ActorsInRadius(pixel.V(520, 500), 25))
+
+	scene.RemoveActor("hero")
+	assert.Empty(t, scene.ActorsInRect(pixel.R(490, 490, 510, 510)), "The hero has left the scene.")
+	assert.Empty(t, hero.grids, "The hero should no longer update the index.")
+
+	// Actors added directly are picked up, while those replaced directly
+	// need a reindex.
+	scene.Actors["hero"] = hero
+	assert.Len(t, scene.ActorsInRect(pixel.R(490, 490, 510, 510)), 1)
+	double := newTestActor("hero", pixel.V(100, 100))
+	scene.Actors["hero"] = double
+	scene.Reindex()
+	assert.Empty(t, scene.ActorsInRect(pixel.R(490, 490, 510, 510)), "The old hero should be gone.")
+	assert.Equal(t, []*Actor{double}, scene.ActorsInRect(pixel.R(90, 90, 110, 110)), "The new hero should be found.")
+}
+
+// benchmarkScene will build a scene the size of a 200x200 tile map, full of
+// walls and wandering actors.
+func benchmarkScene() *Scene {
+	random := rand.New(rand.NewSource(1))
+	size := 200 * 32.0
+
+	m := &Map{Size: pixel.V(size, size)}
+	for i := 0; i < 5000; i++ {
+		x, y := random.Float64()*size, random.Float64()*size
+		m.Collision = append(m.Collision, &RectShape{Rect: pixel.R(x, y, x+32, y+32)})
+	}
+
+	scene := &Scene{Engine: &Engine{}, Actors: make(map[string]*Actor), MapData: m}
+	for i := 0; i < 300; i++ {
+		a := newTestActor("npc"+strconv.Itoa(i), pixel.V(random.Float64()*size, random.Float64()*size))
+		a.Collision = true
+		a.CollisionLayer = 1
+		a.CollisionMask = CollisionAll
+		scene.Actors[a.ID] = a
+	}
+
+	return scene
+}
+
+// linearCollisionFree is how CollisionFree worked before the index, checking
+// every shape.
+func linearCollisionFree(s *Scene, clip pixel.Rect) bool {
+	for _, c := range s.MapData.Collision {
+		if c.IntersectsRect(clip) {
+			return false
+		}
+	}
+	return true
+}
+
+// linearActorsInRect is how ActorsInRect worked before the index, checking
+// every actor.
+func linearActorsInRect(s *Scene, area pixel.Rect) []*Actor {
+	return filterActors(s.Actors, func(a *Actor) bool {
+		return a.Clip.Intersects(area)
+	})
+}
+
+func BenchmarkCollisionFreeLinear(b *testing.B) {
+	scene := benchmarkScene()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			linearCollisionFree(scene, a.Clip)
+		}
+	}
+}
+
+func BenchmarkCollisionFree(b *testing.B) {
+	scene := benchmarkScene()
+	scene.MapData.CollisionNear(pixel.Rect{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			scene.CollisionFree(a.Clip)
+		}
+	}
+}
+
+func BenchmarkActorsInRectLinear(b *testing.B) {
+	scene := benchmarkScene()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			linearActorsInRect(scene, a.Clip)
+		}
+	}
+}
+
+func BenchmarkActorsInRect(b *testing.B) {
+	scene := benchmarkScene()
+	scene.actorIndex()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			scene.ActorsInRect(a.Clip)
+		}
+	}
+}
+
+func BenchmarkActorsInRectMovingLinear(b *testing.B) {
+	scene := benchmarkScene()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			a.Move(pixel.V(1, 0))
+			linearActorsInRect(scene, a.Clip)
+		}
+	}
+}
+
+func BenchmarkActorsInRectMoving(b *testing.B) {
+	scene := benchmarkScene()
+	scene.actorIndex()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			a.Move(pixel.V(1, 0))
+			scene.ActorsInRect(a.Clip)
+		}
+	}
+}
+
+func BenchmarkResolveMovement(b *testing.B) {
+	scene := benchmarkScene()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, a := range scene.Actors {
+			a.Move(scene.ResolveMovement(a, pixel.V(2, 1)))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package gamesys
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// GameState is a saved game, holding the script variables and where every
+// actor was and what it was doing.
+type GameState struct {
+	XMLName   xml.Name        `xml:"gamestate"`
+	Variables []StateVariable `xml:"variables>variable"`
+	Actors    []ActorState    `xml:"actors>actor"`
+}
+
+// StateVariable is a saved script variable.
+type StateVariable struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// ActorState is a saved actor. Attached actors keep the id of their parent
+// along with their offset.
+type ActorState struct {
+	ID         string             `xml:"id,attr"`
+	Parent     string             `xml:"parent,attr,omitempty"`
+	X          float64            `xml:"x,attr"`
+	Y          float64            `xml:"y,attr"`
+	OffsetX    float64            `xml:"offsetx,attr"`
+	OffsetY    float64            `xml:"offsety,attr"`
+	Facing     int                `xml:"facing,attr"`
+	Layer      int                `xml:"layer,attr"`
+	Visible    bool               `xml:"visible,attr"`
+	Speed      float64            `xml:"speed,attr"`
+	Tags       string             `xml:"tags,attr,omitempty"`
+	Properties []TemplateProperty `xml:"property"`
+}
+
+// SaveState will save the game state to the file.
+func (e *Engine) SaveState(file string) error {
+	state := &GameState{}
+
+	// Keep things in order so saves are easy to compare.
+	names := make([]string, 0, len(e.Variables))
+	for name := range e.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		state.Variables = append(state.Variables, StateVariable{Name: name, Value: e.Variables[name]})
+	}
+
+	for _, a := range filterActors(e.Actors, func(a *Actor) bool { return true }) {
+		saved := ActorState{ID: a.ID, X: a.Position.X, Y: a.Position.Y, Facing: a.Facing, Layer: a.Layer, Visible: a.Visible, Speed: a.Speed, Tags: strings.Join(a.Tags, ",")}
+		if a.Parent != nil {
+			saved.Parent = a.Parent.ID
+			saved.OffsetX, saved.OffsetY = a.Offset.X, a.Offset.Y
+		}
+
+		properties := make([]string, 0, len(a.Properties))
+		for name := range a.Properties {
+			properties = append(properties, name)
+		}
+		sort.Strings(properties)
+		for _, name := range properties {
+			saved.Properties = append(saved.Properties, TemplateProperty{Name: name, Type: propertyType(a.Properties[name]), Value: a.Properties.String(name)})
+		}
+
+		state.Actors = append(state.Actors, saved)
+	}
+
+	byteValue, err := xml.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append([]byte(xml.Header), byteValue...), 0644)
+}
+
+// LoadState will load the game state from the file. Actors need to exist
+// already, as only their state is restored.
+func (e *Engine) LoadState(file string) error {
+	xmlFile, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer xmlFile.Close()
+
+	byteValue, _ := ioutil.ReadAll(xmlFile)
+	state := &GameState{}
+	err = xml.Unmarshal(byteValue, state)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range state.Variables {
+		e.SetVariable(v.Name, v.Value)
+	}
+
+	// Restore everyone before linking parents, so they can be in any order.
+	for _, saved := range state.Actors {
+		a, ok := e.Actors[saved.ID]
+		if !ok {
+			return errors.New("loadstate: actor " + saved.ID + " not found")
+		}
+
+		a.Detach()
+		a.Facing = saved.Facing
+		a.Layer = saved.Layer
+		a.Visible = saved.Visible
+		a.Speed = saved.Speed
+		a.Tags = ParseTags(saved.Tags)
+		a.Properties = make(Properties)
+		for _, p := range saved.Properties {
+			a.Properties[p.Name] = ParseProperty(p.Type, p.Value)
+		}
+		a.MoveTo(pixel.V(saved.X, saved.Y))
+	}
+
+	for _, saved := range state.Actors {
+		if saved.Parent == "" {
+			continue
+		}
+
+		parent, ok := e.Actors[saved.Parent]
+		if !ok {
+			return errors.New("loadstate: parent " + saved.Parent + " not found")
+		}
+		err = e.Actors[saved.ID].Attach(parent, pixel.V(saved.OffsetX, saved.OffsetY))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// propertyType will return the Tiled type name of the property value.
+func propertyType(value interface{}) string {
+	switch value.(type) {
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	}
+	return "string"
+}
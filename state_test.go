@@ -0,0 +1,47 @@
+package gamesys
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gamesys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "save.xml")
+
+	e := &Engine{Actors: make(map[string]*Actor), Variables: make(map[string]string)}
+	hero := newTestActor("hero", pixel.V(100, 100))
+	sword := newTestActor("sword", pixel.ZV)
+	e.AddActor("hero", hero)
+	e.AddActor("sword", sword)
+	assert.NoError(t, sword.Attach(hero, pixel.V(10, 0)))
+	hero.SetProperty("gold", 12)
+	hero.Tag("player")
+	e.SetVariable("chapter", "2")
+
+	assert.NoError(t, e.SaveState(file))
+
+	// Mess everything up, then bring it back.
+	sword.Detach()
+	hero.MoveTo(pixel.V(0, 0))
+	hero.Properties = nil
+	hero.Tags = nil
+	e.SetVariable("chapter", "1")
+
+	assert.NoError(t, e.LoadState(file))
+	assert.Equal(t, pixel.V(100, 100), hero.Position)
+	assert.Equal(t, hero, sword.Parent, "Parent links should be restored.")
+	assert.Equal(t, pixel.V(110, 100), sword.Position)
+	assert.Equal(t, 12, hero.Properties["gold"], "Properties keep their type.")
+	assert.True(t, hero.HasTag("player"))
+	assert.Equal(t, "2", e.GetVariable("chapter"))
+
+	assert.Error(t, e.LoadState("blahblah"), "We should throw an error on bad file load")
+}
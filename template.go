@@ -0,0 +1,236 @@
+package gamesys
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// ActorTemplate is a reusable actor definition, such as a "guard". Actors
+// are spawned from templates by name, and templates can extend each other.
+// Fields are the template attributes, which are:
+//
+//	image, animations, speed, visible, layer, collision, radius,
+//	collisionlayer, collisionmask, trigger, behaviour and tags
+//
+// Behaviour options are read from the template properties, the same as the
+// Tiled properties.
+type ActorTemplate struct {
+	// XMLName is how we reference when loading xml information.
+	XMLName xml.Name `xml:"template"`
+
+	// Name is how the template is referenced.
+	Name string `xml:"name,attr"`
+
+	// Extends is the name of the template this one builds on.
+	Extends string `xml:"extends,attr"`
+
+	// Attributes holds every other attribute, being the template fields.
+	Attributes []xml.Attr `xml:",any,attr"`
+
+	// Properties are the custom properties given to spawned actors.
+	Properties []TemplateProperty `xml:"property"`
+}
+
+// TemplateProperty is a typed custom property of a template.
+type TemplateProperty struct {
+	XMLName xml.Name `xml:"property"`
+	Name    string   `xml:"name,attr"`
+	Type    string   `xml:"type,attr"`
+	Value   string   `xml:"value,attr"`
+}
+
+// ActorTemplates is a file full of templates.
+type ActorTemplates struct {
+	XMLName   xml.Name         `xml:"templates"`
+	Templates []*ActorTemplate `xml:"template"`
+}
+
+// resolvedTemplate is a template with its whole family merged together.
+type resolvedTemplate struct {
+	fields     map[string]string
+	properties Properties
+	tags       []string
+}
+
+// LoadTemplates will load actor templates from the provided XML file, adding
+// them to those already loaded.
+func (e *Engine) LoadTemplates(file string) error {
+	xmlFile, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer xmlFile.Close()
+
+	byteValue, _ := ioutil.ReadAll(xmlFile)
+	templates := &ActorTemplates{}
+	err = xml.Unmarshal(byteValue, templates)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range templates.Templates {
+		e.Templates[t.Name] = t
+	}
+
+	return nil
+}
+
+// resolveTemplate will merge the template with everything it extends, the
+// template itself winning over its parents. Tags are collected from all of
+// them. Templates that extend themselves are rejected.
+func (e *Engine) resolveTemplate(name string) (*resolvedTemplate, error) {
+	// Find the family, from the template up to the oldest parent.
+	family := make([]*ActorTemplate, 0)
+	seen := make(map[string]bool)
+	for name != "" {
+		if seen[name] {
+			return nil, errors.New("template: " + name + " extends itself")
+		}
+		seen[name] = true
+
+		t, ok := e.Templates[name]
+		if !ok {
+			return nil, errors.New("template: " + name + " not found")
+		}
+		family = append(family, t)
+		name = t.Extends
+	}
+
+	// Now merge down from the oldest parent.
+	resolved := &resolvedTemplate{fields: make(map[string]string), properties: make(Properties), tags: make([]string, 0)}
+	for i := len(family) - 1; i >= 0; i-- {
+		for _, a := range family[i].Attributes {
+			if a.Name.Local == "tags" {
+				resolved.tags = append(resolved.tags, ParseTags(a.Value)...)
+				continue
+			}
+			resolved.fields[a.Name.Local] = a.Value
+		}
+		for _, p := range family[i].Properties {
+			resolved.properties[p.Name] = ParseProperty(p.Type, p.Value)
+		}
+	}
+
+	return resolved, nil
+}
+
+// SpawnActor will create an actor from a template, adding it to the engine
+// and the scene. Overrides replace template fields by name, and anything
+// that isn't a field is set as a property. The "collide" Tiled property is
+// treated as the collision field. Properties keep the type of their override.
+// Ids already in use and images that can't be loaded are errors.
+func (e *Engine) SpawnActor(scene *Scene, id string, template string, position pixel.Vec, overrides Properties) (*Actor, error) {
+	if _, ok := e.Actors[id]; ok {
+		return nil, errors.New("spawnactor: actor " + id + " already exists")
+	}
+
+	resolved, err := e.resolveTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply our overrides over the template.
+	fieldNames := []string{"image", "animations", "speed", "visible", "layer", "collision", "radius", "collisionlayer", "collisionmask", "trigger", "behaviour"}
+	for name, value := range overrides {
+		field := name
+		if field == "collide" {
+			field = "collision"
+		}
+
+		switch {
+		case field == "tags":
+			resolved.tags = append(resolved.tags, ParseTags(overrides.String(name))...)
+		case contains(fieldNames, field):
+			resolved.fields[field] = overrides.String(name)
+		default:
+			resolved.properties[name] = value
+		}
+	}
+	fields := resolved.fields
+
+	// We can't create an actor without something to show.
+	if fields["image"] == "" {
+		return nil, errors.New("template: " + template + " has no image")
+	}
+
+	// Create actor and populate fields.
+	newActor, err := e.newActor(fields["image"], position)
+	if err != nil {
+		return nil, err
+	}
+	newActor.Visible = StrBool(fields["visible"])
+	if speed, ok := fields["speed"]; ok {
+		newActor.Speed = StrFloat(speed)
+	}
+	newActor.Layer = int(StrFloat(fields["layer"]))
+	if collision, ok := fields["collision"]; ok {
+		newActor.Collision = StrBool(collision)
+	}
+	newActor.Radius = StrFloat(fields["radius"])
+	if layer, ok := fields["collisionlayer"]; ok {
+		newActor.CollisionLayer = uint32(StrFloat(layer))
+	}
+	if mask, ok := fields["collisionmask"]; ok {
+		newActor.CollisionMask = uint32(StrFloat(mask))
+	}
+	newActor.Trigger = StrBool(fields["trigger"])
+	newActor.Properties = resolved.properties
+	newActor.Tag(resolved.tags...)
+
+	if animations, ok := fields["animations"]; ok {
+		err = e.LoadActorAnimations(newActor, animations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Behaviours take their options from our properties. They are set up
+	// before we join in, so a bad behaviour leaves nothing behind.
+	if kind, ok := fields["behaviour"]; ok {
+		options := make(map[string]string)
+		for name := range resolved.properties {
+			options[name] = resolved.properties.String(name)
+		}
+		newActor.Behaviour, err = scene.NewBehaviour(newActor, kind, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Add to our engine, and use on the scene.
+	e.AddActor(id, newActor)
+	err = scene.UseActor(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return newActor, nil
+}
+
+// ParseOverrides will read script arguments written as key=value into a
+// collection of overrides.
+func ParseOverrides(args []interface{}) Properties {
+	overrides := make(Properties)
+	for _, a := range args {
+		pair := strings.SplitN(a.(string), "=", 2)
+		if len(pair) == 2 {
+			overrides[pair[0]] = pair[1]
+		}
+	}
+	return overrides
+}
+
+// contains will indicate if the list holds the value.
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
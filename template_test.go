@@ -0,0 +1,66 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpawnActor(t *testing.T) {
+	e := testEngine
+	assert.Error(t, e.LoadTemplates("blahblah"), "We should throw an error on bad file load")
+	assert.NoError(t, e.LoadTemplates("test_assets/characters/templates.xml"))
+
+	scene := e.GetScene("test2")
+
+	// Our captain builds on the guard.
+	captain, err := e.SpawnActor(scene, "captain", "guard_captain", pixel.V(100, 100), Properties{"speed": "2", "mood": "grumpy", "hp": 40})
+	defer e.RemoveActor("captain")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2.0, captain.Speed, "Overrides should win.")
+	assert.True(t, captain.Visible, "Fields should be inherited.")
+	assert.Equal(t, 40, captain.Properties["hp"], "Overrides should keep their type.")
+	assert.Equal(t, 64.0, captain.Properties.Float("wanderRadius"), "Parent properties should be inherited.")
+	assert.Equal(t, "grumpy", captain.Properties.String("mood"), "Other overrides become properties.")
+	assert.Equal(t, []string{"npc", "guard", "captain"}, captain.Tags, "Tags should be collected.")
+	assert.IsType(t, &Wander{}, captain.Behaviour, "Behaviour should be inherited.")
+	assert.Equal(t, captain, scene.Actors["captain"], "We should be on the scene.")
+
+	// Broken templates.
+	_, err = e.SpawnActor(scene, "loop", "loop_a", pixel.ZV, nil)
+	assert.Error(t, err, "Templates extending themselves should be rejected.")
+	_, err = e.SpawnActor(scene, "nobody", "nobody", pixel.ZV, nil)
+	assert.Error(t, err, "Missing templates should be rejected.")
+
+	// Ids are only used once.
+	_, err = e.SpawnActor(scene, "captain", "guard", pixel.ZV, nil)
+	assert.Error(t, err, "Ids already in use should be rejected.")
+	assert.Equal(t, captain, e.Actors["captain"], "The first actor should be kept.")
+
+	// Images that won't load are reported, rather than exiting.
+	_, err = e.SpawnActor(scene, "ghost", "guard", pixel.ZV, Properties{"image": "nothing.png"})
+	assert.Error(t, err, "Missing images should be rejected.")
+	assert.NotContains(t, e.Actors, "ghost", "We should not be on the engine.")
+
+	// A bad behaviour leaves nothing behind.
+	_, err = e.SpawnActor(scene, "confused", "guard", pixel.ZV, Properties{"behaviour": "dance"})
+	assert.Error(t, err, "Unknown behaviours should be rejected.")
+	assert.NotContains(t, e.Actors, "confused", "We should not be on the engine.")
+	assert.NotContains(t, scene.Actors, "confused", "We should not be on the scene.")
+
+	// Map spawns pass their typed properties along.
+	assert.NoError(t, e.NewScene("templated", "black"))
+	defer delete(e.Scenes, "templated")
+	defer delete(e.Actors, "mapguard")
+	assert.NoError(t, e.Scenes["templated"].LoadMap("test_assets/maps/templated.tmx"))
+	assert.Contains(t, e.ActorsWithProperty("hp", 10), e.Actors["mapguard"], "Map overrides should keep their type.")
+	assert.Equal(t, 0.5, e.Actors["mapguard"].Speed, "Template fields should be kept.")
+}
+
+func TestParseOverrides(t *testing.T) {
+	overrides := ParseOverrides([]interface{}{"speed=2", "tags=a,b", "junk"})
+	assert.Equal(t, Properties{"speed": "2", "tags": "a,b"}, overrides)
+}
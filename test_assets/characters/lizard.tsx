@@ -0,0 +1,4 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.4" tiledversion="1.4.2" name="Lizard" tilewidth="16" tileheight="16" tilecount="4" columns="2">
+ <image source="lizard.png" width="32" height="32"/>
+</tileset>
@@ -0,0 +1,311 @@
+package gamesys
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// TextInput collects typed characters, for name entry and dialogs. While a
+// text input is running on the controller, it receives all of the input.
+type TextInput struct {
+	// Text is the entered text so far.
+	Text []rune
+
+	// Cursor is the position of the cursor within the text.
+	Cursor int
+
+	// Anchor is where the selection started. When it matches the cursor
+	// there is no selection.
+	Anchor int
+
+	// MaxLength is the most characters allowed, 0 for no limit.
+	MaxLength int
+
+	// Class limits the characters allowed. Use "alpha", "numeric", "alnum"
+	// or "name", anything else allows all printable characters.
+	Class string
+
+	// OnSubmit is called with the text when entry is accepted.
+	OnSubmit func(string)
+
+	// OnCancel is called when entry is abandoned.
+	OnCancel func()
+}
+
+// NewTextInput will create an empty text input.
+func NewTextInput(maxlength int, class string) *TextInput {
+	return &TextInput{Text: make([]rune, 0), MaxLength: maxlength, Class: class}
+}
+
+// String will return the entered text.
+func (t *TextInput) String() string {
+	return string(t.Text)
+}
+
+// Allowed will indicate if the character passes our character class.
+func (t *TextInput) Allowed(r rune) bool {
+	if !unicode.IsPrint(r) {
+		return false
+	}
+
+	switch t.Class {
+	case "alpha":
+		return unicode.IsLetter(r)
+	case "numeric":
+		return unicode.IsDigit(r)
+	case "alnum":
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	case "name":
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-' || r == '\''
+	}
+
+	return true
+}
+
+// Selection will return the start and end of the selected text.
+func (t *TextInput) Selection() (int, int) {
+	if t.Anchor < t.Cursor {
+		return t.Anchor, t.Cursor
+	}
+	return t.Cursor, t.Anchor
+}
+
+// HasSelection will indicate if any text is selected.
+func (t *TextInput) HasSelection() bool {
+	return t.Anchor != t.Cursor
+}
+
+// Insert will type the characters at the cursor, replacing any selection.
+// Characters outside of our class or past the max length are dropped.
+// Inserting nothing leaves the selection alone.
+func (t *TextInput) Insert(s string) {
+	if s == "" {
+		return
+	}
+	t.deleteSelection()
+
+	for _, r := range s {
+		if !t.Allowed(r) {
+			continue
+		}
+		if t.MaxLength > 0 && len(t.Text) >= t.MaxLength {
+			break
+		}
+
+		t.Text = append(t.Text[:t.Cursor], append([]rune{r}, t.Text[t.Cursor:]...)...)
+		t.Cursor++
+	}
+
+	t.Anchor = t.Cursor
+}
+
+// Backspace will remove the selection, or the character before the cursor.
+func (t *TextInput) Backspace() {
+	if t.deleteSelection() || t.Cursor == 0 {
+		return
+	}
+
+	t.Text = append(t.Text[:t.Cursor-1], t.Text[t.Cursor:]...)
+	t.Cursor--
+	t.Anchor = t.Cursor
+}
+
+// Delete will remove the selection, or the character after the cursor.
+func (t *TextInput) Delete() {
+	if t.deleteSelection() || t.Cursor == len(t.Text) {
+		return
+	}
+
+	t.Text = append(t.Text[:t.Cursor], t.Text[t.Cursor+1:]...)
+}
+
+// MoveCursor will move the cursor by the given number of characters. When
+// selecting, the selection is extended instead of cleared.
+func (t *TextInput) MoveCursor(amount int, selecting bool) {
+	t.SetCursor(t.Cursor+amount, selecting)
+}
+
+// SetCursor will place the cursor at an absolute position within the text.
+func (t *TextInput) SetCursor(position int, selecting bool) {
+	if position < 0 {
+		position = 0
+	} else if position > len(t.Text) {
+		position = len(t.Text)
+	}
+
+	t.Cursor = position
+	if !selecting {
+		t.Anchor = t.Cursor
+	}
+}
+
+// SelectAll will select all of the text.
+func (t *TextInput) SelectAll() {
+	t.Anchor = 0
+	t.Cursor = len(t.Text)
+}
+
+// Submit will accept the entered text.
+func (t *TextInput) Submit() {
+	if t.OnSubmit != nil {
+		t.OnSubmit(t.String())
+	}
+}
+
+// Cancel will abandon text entry.
+func (t *TextInput) Cancel() {
+	if t.OnCancel != nil {
+		t.OnCancel()
+	}
+}
+
+// deleteSelection will remove the selected text, indicating if there was
+// anything to remove.
+func (t *TextInput) deleteSelection() bool {
+	if !t.HasSelection() {
+		return false
+	}
+
+	start, end := t.Selection()
+	t.Text = append(t.Text[:start], t.Text[end:]...)
+	t.Cursor = start
+	t.Anchor = start
+
+	return true
+}
+
+// StartTextInput will begin collecting typed text. Handlers will not run
+// until the text input is submitted or cancelled.
+func (c *Controller) StartTextInput(input *TextInput) {
+	c.Text = input
+
+	// Throw away anything typed before we started.
+	_ = c.Engine.win.Typed()
+}
+
+// StopTextInput will stop collecting typed text.
+func (c *Controller) StopTextInput() {
+	c.Text = nil
+}
+
+// processText will feed the window input to our running text input.
+func (c *Controller) processText() {
+	win := c.Engine.win
+	input := c.Text
+	selecting := win.Pressed(pixelgl.KeyLeftShift) || win.Pressed(pixelgl.KeyRightShift)
+	ctrl := win.Pressed(pixelgl.KeyLeftControl) || win.Pressed(pixelgl.KeyRightControl)
+
+	input.Insert(win.Typed())
+
+	switch {
+	case win.JustPressed(pixelgl.KeyEnter):
+		// Stop first, so the callback is free to start another input.
+		c.StopTextInput()
+		input.Submit()
+	case win.JustPressed(pixelgl.KeyEscape):
+		c.StopTextInput()
+		input.Cancel()
+	case ctrl && win.JustPressed(pixelgl.KeyA):
+		input.SelectAll()
+	case win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace):
+		input.Backspace()
+	case win.JustPressed(pixelgl.KeyDelete) || win.Repeated(pixelgl.KeyDelete):
+		input.Delete()
+	case win.JustPressed(pixelgl.KeyLeft) || win.Repeated(pixelgl.KeyLeft):
+		input.MoveCursor(-1, selecting)
+	case win.JustPressed(pixelgl.KeyRight) || win.Repeated(pixelgl.KeyRight):
+		input.MoveCursor(1, selecting)
+	case win.JustPressed(pixelgl.KeyHome):
+		input.SetCursor(0, selecting)
+	case win.JustPressed(pixelgl.KeyEnd):
+		input.SetCursor(len(input.Text), selecting)
+	}
+}
+
+// DisplayTextInput will show a prompt on screen, collecting typed text until
+// it is submitted or cancelled. It uses the messagebox configuration.
+func (e *Engine) DisplayTextInput(prompt string, input *TextInput) {
+	// Grab our configuration options for simplicity.
+	msgConfig := e.Config.Default.MessageBox
+	scene := e.ActiveScene
+
+	scene.NewView("textinput", pixel.V(msgConfig.X, msgConfig.Y), pixel.R(0, 0, msgConfig.Width, msgConfig.Height), msgConfig.BGColor)
+	inputView, err := scene.GetView("textinput")
+	if err != nil {
+		panic(err)
+	}
+	inputView.Show()
+
+	inputView.DesignView = func() {
+		inputView.Rendered.Clear(colornames.Map[msgConfig.BGColor])
+		inputTxt := text.New(pixel.ZV, e.Font)
+		inputTxt.Color = colornames.Map[msgConfig.Color]
+
+		// Mark our cursor, and brackets around any selection.
+		entered := input.Text
+		start, end := input.Selection()
+		shown := string(entered[:start])
+		if input.HasSelection() {
+			shown += "[" + string(entered[start:end]) + "]"
+		} else {
+			shown += "_"
+		}
+		shown += string(entered[end:])
+
+		fmt.Fprintf(inputTxt, "%s\n%s", prompt, shown)
+
+		offset := inputTxt.LineHeight
+		inputView.Rendered.SetMatrix(pixel.IM.Moved(pixel.V(2, msgConfig.Height-offset+2)))
+		inputTxt.Draw(inputView.Rendered, pixel.IM)
+		inputView.Rendered.SetMatrix(pixel.IM)
+	}
+
+	// Remove our view whichever way entry ends.
+	submit := input.OnSubmit
+	input.OnSubmit = func(value string) {
+		scene.RemoveView("textinput")
+		if submit != nil {
+			submit(value)
+		}
+	}
+	cancel := input.OnCancel
+	input.OnCancel = func() {
+		scene.RemoveView("textinput")
+		if cancel != nil {
+			cancel()
+		}
+	}
+
+	e.Control.StartTextInput(input)
+}
+
+// SetVariable will store a script variable.
+func (e *Engine) SetVariable(name string, value string) {
+	e.Variables[name] = value
+}
+
+// GetVariable will return a script variable, empty if not set.
+func (e *Engine) GetVariable(name string) string {
+	return e.Variables[name]
+}
+
+// expandVariables will replace any `$name` arguments with the value of the
+// script variable, leaving unknown variables as they are.
+func (e *Engine) expandVariables(args []interface{}) []interface{} {
+	expanded := make([]interface{}, len(args))
+	for i, a := range args {
+		expanded[i] = a
+		if s, ok := a.(string); ok && strings.HasPrefix(s, "$") {
+			if value, ok := e.Variables[s[1:]]; ok {
+				expanded[i] = value
+			}
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,92 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextInputEditing(t *testing.T) {
+	input := NewTextInput(8, "name")
+
+	// Typing respects our class and max length.
+	input.Insert("Ar!thur Pendragon")
+	assert.Equal(t, "Arthur P", input.String(), "Invalid characters and overflow should be dropped.")
+
+	// Backspace and delete around the cursor.
+	input.Backspace()
+	input.Backspace()
+	input.MoveCursor(-6, false)
+	input.Delete()
+	assert.Equal(t, "rthur", input.String(), "We should remove either side of the cursor.")
+	assert.Equal(t, 0, input.Cursor, "Cursor should be at the start.")
+
+	// Selection is replaced when typing.
+	input.MoveCursor(1, false)
+	input.MoveCursor(4, true)
+	assert.True(t, input.HasSelection(), "We should have a selection.")
+	input.Insert("")
+	assert.True(t, input.HasSelection(), "Typing nothing should keep our selection.")
+	assert.Equal(t, "rthur", input.String(), "Typing nothing should keep our text.")
+	input.Insert("ob")
+	assert.Equal(t, "rob", input.String(), "Selection should be replaced.")
+
+	// Select all and wipe it out.
+	input.SelectAll()
+	input.Backspace()
+	assert.Equal(t, "", input.String(), "Everything should be removed.")
+}
+
+func TestTextInputCallbacks(t *testing.T) {
+	input := NewTextInput(0, "")
+	submitted := ""
+	cancelled := false
+	input.OnSubmit = func(value string) { submitted = value }
+	input.OnCancel = func() { cancelled = true }
+
+	input.Insert("Hero")
+	input.Submit()
+	input.Cancel()
+
+	assert.Equal(t, "Hero", submitted, "Submit should pass along our text.")
+	assert.True(t, cancelled, "Cancel should be called.")
+}
+
+func TestExpandVariables(t *testing.T) {
+	testEngine.SetVariable("hero", "Ann")
+	args := testEngine.expandVariables([]interface{}{"$hero", "$nobody", "plain"})
+
+	assert.Equal(t, []interface{}{"Ann", "$nobody", "plain"}, args, "Only known variables should be replaced.")
+}
+
+func TestTextInputScriptAction(t *testing.T) {
+	e := testEngine
+	said := make([]interface{}, 0)
+	remember := NewScriptAction("Remember", func(args []interface{}) interface{} {
+		said = append(said, args[0])
+		return nil
+	})
+	e.ScriptActions[remember.Action] = remember
+	defer delete(e.ScriptActions, remember.Action)
+	defer delete(e.Variables, "pet")
+
+	// The script doesn't wait, so the variable isn't set yet.
+	script := NewScript()
+	script.Add("TextInput", "pet", "8", "name", "Name", "your", "pet?")
+	script.Add("Remember", "$pet")
+	e.RunScript(script)
+	if !assert.NotNil(t, e.Control.Text, "We should be collecting text.") {
+		return
+	}
+	assert.Equal(t, []interface{}{"$pet"}, said, "Unset variables are left alone.")
+
+	// Scripts run after entry see the value.
+	input := e.Control.Text
+	e.Control.StopTextInput()
+	input.Insert("Rex")
+	input.Submit()
+	after := NewScript()
+	after.Add("Remember", "$pet")
+	e.RunScript(after)
+	assert.Equal(t, []interface{}{"$pet", "Rex"}, said, "Later scripts should see the variable.")
+}
@@ -0,0 +1,125 @@
+package gamesys
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// loadTileCollision will add collision from the tile layers, being the
+// shapes drawn on tiles in the Tiled tileset editor, and whole tiles with
+// the "solid" tile property.
+func (m *Map) loadTileCollision() {
+	tiles := m.tilesetTiles()
+
+	for i, layer := range m.Src.Layers {
+		m.eachTile(i, func(column int, row int, tile *tiled.LayerTile) {
+			info, ok := tiles[tile.Tileset][tile.ID]
+			if !ok {
+				return
+			}
+
+			if info.Properties.GetBool("solid") {
+				m.addCollision(m.TileShape(column, row))
+				return
+			}
+
+			for _, group := range info.ObjectGroups {
+				for _, obj := range group.Objects {
+					m.addCollision(m.tileShape(layer, column, row, tile, info, obj))
+				}
+			}
+		})
+	}
+}
+
+// SolidLayer will make every tile on the named tile layer solid, for maps
+// that paint their walls on a layer of their own. Maps without the layer
+// are left alone.
+func (m *Map) SolidLayer(name string) {
+	for i, layer := range m.Src.Layers {
+		if layer.Name != name {
+			continue
+		}
+
+		m.eachTile(i, func(column int, row int, tile *tiled.LayerTile) {
+			m.addCollision(m.TileShape(column, row))
+		})
+	}
+}
+
+// TileRect will return the map area of the box around the tile, counting
+// columns from the left and rows from the top like Tiled does.
+func (m *Map) TileRect(column int, row int) pixel.Rect {
+	size := m.Grid.TileSize
+	corner := m.toPixel(m.Grid.CellOrigin(column, row))
+	return pixel.R(corner.X, corner.Y-size.Y, corner.X+size.X, corner.Y)
+}
+
+// tileShape will return the collision shape drawn on a tile, placed on the
+// map just as the tile is drawn. Shapes are drawn on the tile image, which
+// sits on the bottom left of its cell and can be bigger than it, so they
+// follow the image along with the layer and tileset offsets and any flips.
+func (m *Map) tileShape(l *tiled.Layer, column int, row int, tile *tiled.LayerTile, info *tiled.TilesetTile, obj *tiled.Object) Shape {
+	size := pixel.V(float64(tile.Tileset.TileWidth), float64(tile.Tileset.TileHeight))
+	if info.Image != nil && info.Image.Width > 0 {
+		size = pixel.V(float64(info.Image.Width), float64(info.Image.Height))
+	}
+	matrix := m.Tiles.tileMatrix(l, column, row, tile, size)
+
+	// Every flip mirrors the shape, so two of them cancel out.
+	mirrored := tile.HorizontalFlip != tile.VerticalFlip != tile.DiagonalFlip
+	return objectShape(obj, mirrored, func(p pixel.Vec) pixel.Vec {
+		// Tile positions run down from the top left of the image.
+		return matrix.Project(pixel.V(p.X-size.X/2, size.Y/2-p.Y))
+	})
+}
+
+// tilesetTiles will index the tiles with extra data by tileset and id.
+func (m *Map) tilesetTiles() map[*tiled.Tileset]map[uint32]*tiled.TilesetTile {
+	tiles := make(map[*tiled.Tileset]map[uint32]*tiled.TilesetTile)
+	for _, ts := range m.Src.Tilesets {
+		tiles[ts] = make(map[uint32]*tiled.TilesetTile)
+		for _, t := range ts.Tiles {
+			tiles[ts][t.ID] = t
+		}
+	}
+	return tiles
+}
+
+// eachTile will run the action for every tile placed on the layer. Infinite
+// maps only look through their chunks.
+func (m *Map) eachTile(layer int, action func(column int, row int, tile *tiled.LayerTile)) {
+	if m.chunks != nil {
+		chunks := m.chunks[layer]
+		for _, key := range chunks.keys() {
+			for i, tile := range chunks.Tiles[key] {
+				if !tile.IsNil() {
+					action(key.X*chunks.Size.X+i%chunks.Size.X, key.Y*chunks.Size.Y+i/chunks.Size.X, tile)
+				}
+			}
+		}
+		return
+	}
+
+	for i, tile := range m.Src.Layers[layer].Tiles {
+		if tile.IsNil() {
+			continue
+		}
+		action(i%m.Src.Width, i/m.Src.Width, tile)
+	}
+}
+
+// addCollision will add the shape to the map collision, skipping empty
+// rects.
+func (m *Map) addCollision(shape Shape) {
+	if rect, ok := shape.(*RectShape); ok && rect.Rect.Area() == 0 {
+		return
+	}
+	m.Collision = append(m.Collision, shape)
+
+	// Keep our index up to date once it has been built.
+	if m.collisionGrid != nil && m.indexed == len(m.Collision)-1 {
+		m.collisionGrid.Insert(shape, shape.Bounds())
+		m.indexed++
+	}
+}
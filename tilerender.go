@@ -0,0 +1,491 @@
+package gamesys
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+const (
+	// DefaultChunkSize is how many tiles across and up each chunk of a tile
+	// renderer covers.
+	DefaultChunkSize = 16
+
+	// DefaultMaxChunks is how many chunks a tile renderer keeps before
+	// dropping those drawn least recently.
+	DefaultMaxChunks = 256
+)
+
+// TileRenderer draws the tile layers of a map a chunk of tiles at a time.
+// Only the chunks the camera can see are drawn, each batched by tileset so
+// a chunk takes one draw for each tileset it uses. Chunks are built the
+// first time they are seen and then cached, dropping those drawn least
+// recently once there are too many, so even very large maps only hold the
+// tiles around the camera.
+//
+// Tiles with a Tiled animation are kept out of the chunk batches, and are
+// batched again only when one of them changes frame.
+type TileRenderer struct {
+	// ChunkSize is how many tiles across and up each chunk covers.
+	ChunkSize int
+
+	// MaxChunks is how many chunks are cached at most.
+	MaxChunks int
+
+	// Time is the game time, in seconds, animated tiles are drawn at.
+	Time float64
+
+	// m is the map we draw.
+	m *Map
+
+	// pictures are the tileset images, by file.
+	pictures map[string]pixel.Picture
+
+	// sprites are the sprite for each tile we have drawn, by tileset and id.
+	sprites map[*tiled.Tileset]map[uint32]*pixel.Sprite
+
+	// animations are the animated tiles, by tileset and id.
+	animations map[*tiled.Tileset]map[uint32]*tileAnimation
+
+	// chunks are the cached chunks.
+	chunks map[tileChunkKey]*tileChunk
+
+	// drawn counts our draws, so we know which chunks were drawn last.
+	drawn int
+
+	// overhang is how far tiles can reach past their cell, up and across,
+	// for tilesets with bigger tiles than the map.
+	overhang pixel.Vec
+}
+
+// tileChunkKey is the layer, column and row of a chunk.
+type tileChunkKey struct {
+	layer, column, row int
+}
+
+// tileChunk is the tiles of one chunk of a layer, batched by tileset.
+type tileChunk struct {
+	batches []*pixel.Batch
+	drawn   int
+
+	// animated are the animated tiles of the chunk, batched on their own
+	// with the frames they were batched at.
+	animated        []*animatedTile
+	animatedBatches []*pixel.Batch
+	frames          []uint32
+}
+
+// tileAnimation is the frames of an animated tile.
+type tileAnimation struct {
+	// frames are the tile ids shown, each for its duration in seconds.
+	frames    []uint32
+	durations []float64
+	total     float64
+}
+
+// animatedTile is an animated tile placed on the map.
+type animatedTile struct {
+	tile      *tiled.LayerTile
+	layer     *tiled.Layer
+	index     int
+	column    int
+	row       int
+	animation *tileAnimation
+}
+
+// NewTileRenderer will create a renderer for the map tile layers. Nothing
+// is loaded until it is needed.
+func NewTileRenderer(m *Map) *TileRenderer {
+	r := &TileRenderer{
+		ChunkSize: DefaultChunkSize,
+		MaxChunks: DefaultMaxChunks,
+		m:         m,
+		pictures:  make(map[string]pixel.Picture),
+		sprites:   make(map[*tiled.Tileset]map[uint32]*pixel.Sprite),
+		chunks:    make(map[tileChunkKey]*tileChunk),
+	}
+
+	// Find our animated tiles, Tiled frame durations being milliseconds.
+	r.animations = make(map[*tiled.Tileset]map[uint32]*tileAnimation)
+	for ts, tiles := range m.tilesetTiles() {
+		r.animations[ts] = make(map[uint32]*tileAnimation)
+		for id, t := range tiles {
+			if len(t.Animation) == 0 {
+				continue
+			}
+			animation := &tileAnimation{}
+			for _, f := range t.Animation {
+				animation.frames = append(animation.frames, f.TileID)
+				animation.durations = append(animation.durations, float64(f.Duration)/1000)
+				animation.total += float64(f.Duration) / 1000
+			}
+			r.animations[ts][id] = animation
+		}
+	}
+
+	for _, ts := range m.Src.Tilesets {
+		x, y := float64(ts.TileWidth-m.Src.TileWidth), float64(ts.TileHeight-m.Src.TileHeight)
+		if ts.TileOffset != nil {
+			x += math.Abs(float64(ts.TileOffset.X))
+			y += math.Abs(float64(ts.TileOffset.Y))
+		}
+		r.overhang = pixel.V(math.Max(r.overhang.X, x), math.Max(r.overhang.Y, y))
+	}
+
+	return r
+}
+
+// LoadTilesets will load the images of every tileset up front, so we find
+// out about missing images before we start drawing.
+func (r *TileRenderer) LoadTilesets() error {
+	for _, ts := range r.m.Src.Tilesets {
+		if ts.Image != nil {
+			if _, err := r.picture(ts.GetFileFullPath(ts.Image.Source)); err != nil {
+				return err
+			}
+		}
+		for _, t := range ts.Tiles {
+			if t.Image != nil {
+				if _, err := r.picture(ts.GetFileFullPath(t.Image.Source)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Draw will draw the visible layers that go either above or below the
+// actors, for the part of the map the camera can see. The target is
+// expected to show the camera from its bottom left corner.
+func (r *TileRenderer) Draw(target pixel.Target, camera pixel.Rect, above bool) {
+	for i, layer := range r.m.Layers {
+		if layer.Above != above || !layer.Visible {
+			continue
+		}
+		r.DrawLayer(target, camera, i, pixel.Alpha(layer.Opacity))
+	}
+}
+
+// DrawLayer will draw the chunks of a single layer the camera can see,
+// masked by the color.
+func (r *TileRenderer) DrawLayer(target pixel.Target, camera pixel.Rect, layer int, mask color.Color) {
+	r.drawn++
+	matrix := pixel.IM.Moved(camera.Min.Scaled(-1))
+
+	for _, key := range r.visibleChunks(camera, layer) {
+		chunk, ok := r.chunks[key]
+		if !ok {
+			chunk = r.buildChunk(key)
+			r.chunks[key] = chunk
+		}
+		chunk.drawn = r.drawn
+
+		r.animate(chunk)
+		for _, batches := range [][]*pixel.Batch{chunk.batches, chunk.animatedBatches} {
+			for _, b := range batches {
+				b.SetMatrix(matrix)
+				b.SetColorMask(mask)
+				b.Draw(target)
+			}
+		}
+	}
+
+	r.evict()
+}
+
+// Cached will return how many chunks are cached.
+func (r *TileRenderer) Cached() int {
+	return len(r.chunks)
+}
+
+// Clear will drop every cached chunk, so changed tiles are drawn afresh.
+func (r *TileRenderer) Clear() {
+	r.chunks = make(map[tileChunkKey]*tileChunk)
+}
+
+// visibleChunks will return the chunks of the layer that the camera can
+// see.
+func (r *TileRenderer) visibleChunks(camera pixel.Rect, layer int) []tileChunkKey {
+	keys := make([]tileChunkKey, 0)
+	first, last, ok := r.tileBounds(camera, layer)
+	if !ok {
+		return keys
+	}
+
+	for row := first[1] / r.ChunkSize; row <= last[1]/r.ChunkSize; row++ {
+		for column := first[0] / r.ChunkSize; column <= last[0]/r.ChunkSize; column++ {
+			keys = append(keys, tileChunkKey{layer, column, row})
+		}
+	}
+	return keys
+}
+
+// tileBounds will return the first and last columns and rows of the layer
+// with tiles that can reach into the area, false when none on the map can.
+func (r *TileRenderer) tileBounds(area pixel.Rect, layer int) ([2]int, [2]int, bool) {
+	src := r.m.Src
+	tw, th := float64(src.TileWidth), float64(src.TileHeight)
+	l := src.Layers[layer]
+
+	// Work in the layer's own space, undoing its offset.
+	area = area.Moved(pixel.V(float64(-l.OffsetX), float64(l.OffsetY)))
+
+	// Rows count down from the top like Tiled does. Tiles only touching the
+	// area edge can't be seen.
+	first := [2]int{int(math.Floor((area.Min.X - r.overhang.X) / tw)), int(math.Floor((r.m.Size.Y - area.Max.Y) / th))}
+	last := [2]int{int(math.Ceil(area.Max.X/tw)) - 1, int(math.Ceil((r.m.Size.Y-area.Min.Y+r.overhang.Y)/th)) - 1}
+	if o := r.m.Grid.Orientation; o != OrientationOrthogonal && o != "" {
+		first, last = r.tileRange(area)
+	}
+
+	if last[0] < 0 || last[1] < 0 || first[0] >= src.Width || first[1] >= src.Height {
+		return first, last, false
+	}
+	first = [2]int{clampInt(first[0], 0, src.Width-1), clampInt(first[1], 0, src.Height-1)}
+	last = [2]int{clampInt(last[0], 0, src.Width-1), clampInt(last[1], 0, src.Height-1)}
+	return first, last, true
+}
+
+// tileRange will return the first and last columns and rows that can reach
+// into the camera on maps that aren't square, from the tiles under each
+// corner and those around them.
+func (r *TileRenderer) tileRange(camera pixel.Rect) ([2]int, [2]int) {
+	area := pixel.Rect{Min: camera.Min.Sub(r.overhang), Max: camera.Max}
+	first := [2]int{math.MaxInt32, math.MaxInt32}
+	last := [2]int{math.MinInt32, math.MinInt32}
+	for _, corner := range []pixel.Vec{area.Min, area.Max, pixel.V(area.Min.X, area.Max.Y), pixel.V(area.Max.X, area.Min.Y)} {
+		column, row := r.m.WorldToTile(corner)
+		first = [2]int{minInt(first[0], column-1), minInt(first[1], row-1)}
+		last = [2]int{maxInt(last[0], column+1), maxInt(last[1], row+1)}
+	}
+	return first, last
+}
+
+// buildChunk will batch up the tiles of a chunk, one batch for each tileset
+// image used.
+func (r *TileRenderer) buildChunk(key tileChunkKey) *tileChunk {
+	src := r.m.Src
+	l := src.Layers[key.layer]
+	chunk := &tileChunk{batches: make([]*pixel.Batch, 0)}
+	batches := make(map[pixel.Picture]*pixel.Batch)
+
+	for row := key.row * r.ChunkSize; row < (key.row+1)*r.ChunkSize && row < src.Height; row++ {
+		for _, column := range r.rowOrder(key.column*r.ChunkSize, minInt((key.column+1)*r.ChunkSize, src.Width)) {
+			tile := r.m.Tile(key.layer, column, row)
+			if tile.IsNil() {
+				continue
+			}
+
+			// Animated tiles are batched as they change.
+			if animation, ok := r.animations[tile.Tileset][tile.ID]; ok {
+				chunk.animated = append(chunk.animated, &animatedTile{tile: tile, layer: l, index: key.layer, column: column, row: row, animation: animation})
+				continue
+			}
+
+			r.batchTile(batches, &chunk.batches, l, column, row, tile, tile.ID)
+		}
+	}
+
+	return chunk
+}
+
+// rowOrder will return the columns of a row in the order they are drawn.
+// Maps staggered across shift every other column down, so those are drawn
+// after the ones behind them.
+func (r *TileRenderer) rowOrder(first int, end int) []int {
+	columns := make([]int, 0, end-first)
+	g := &r.m.Grid
+	if !g.staggered() || !g.StaggerX {
+		for column := first; column < end; column++ {
+			columns = append(columns, column)
+		}
+		return columns
+	}
+
+	for _, shifted := range []bool{false, true} {
+		for column := first; column < end; column++ {
+			if g.shifted(column) == shifted {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// animate will batch the animated tiles of the chunk again, if any of them
+// have moved on to another frame.
+func (r *TileRenderer) animate(chunk *tileChunk) {
+	if len(chunk.animated) == 0 {
+		return
+	}
+
+	frames := make([]uint32, len(chunk.animated))
+	changed := len(chunk.frames) != len(frames)
+	for i, a := range chunk.animated {
+		frames[i] = a.animation.frameAt(r.Time)
+		changed = changed || frames[i] != chunk.frames[i]
+	}
+	if !changed {
+		return
+	}
+
+	chunk.frames = frames
+	chunk.animatedBatches = make([]*pixel.Batch, 0)
+	batches := make(map[pixel.Picture]*pixel.Batch)
+	for i, a := range chunk.animated {
+		r.batchTile(batches, &chunk.animatedBatches, a.layer, a.column, a.row, a.tile, frames[i])
+	}
+}
+
+// batchTile will draw the tile showing the given tile id into the batch for
+// its picture, starting a new batch if needed.
+func (r *TileRenderer) batchTile(batches map[pixel.Picture]*pixel.Batch, list *[]*pixel.Batch, l *tiled.Layer, column int, row int, tile *tiled.LayerTile, id uint32) {
+	sprite := r.sprite(tile.Tileset, id)
+	if sprite == nil {
+		return
+	}
+
+	b, ok := batches[sprite.Picture()]
+	if !ok {
+		b = pixel.NewBatch(&pixel.TrianglesData{}, sprite.Picture())
+		batches[sprite.Picture()] = b
+		*list = append(*list, b)
+	}
+	sprite.Draw(b, r.tileMatrix(l, column, row, tile, sprite.Frame().Size()))
+}
+
+// frameAt will return the tile id shown at the game time, looping around.
+func (a *tileAnimation) frameAt(time float64) uint32 {
+	if a.total <= 0 {
+		return a.frames[0]
+	}
+
+	elapsed := math.Mod(time, a.total)
+	for i, d := range a.durations {
+		if elapsed < d {
+			return a.frames[i]
+		}
+		elapsed -= d
+	}
+	return a.frames[len(a.frames)-1]
+}
+
+// tileMatrix will place a tile of the given size in its cell, sitting on
+// the bottom left corner like Tiled does, and flipped as the tile asks.
+func (r *TileRenderer) tileMatrix(l *tiled.Layer, column int, row int, tile *tiled.LayerTile, size pixel.Vec) pixel.Matrix {
+	cell := r.m.TileRect(column, row)
+	corner := cell.Min.Add(pixel.V(float64(l.OffsetX), float64(-l.OffsetY)))
+	if ts := tile.Tileset; ts.TileOffset != nil {
+		corner = corner.Add(pixel.V(float64(ts.TileOffset.X), float64(-ts.TileOffset.Y)))
+	}
+
+	// Tiled flips across the diagonal first, then across and up.
+	matrix := pixel.IM
+	if tile.DiagonalFlip {
+		matrix = pixel.Matrix{0, -1, -1, 0, 0, 0}
+	}
+	if tile.HorizontalFlip {
+		matrix = matrix.ScaledXY(pixel.ZV, pixel.V(-1, 1))
+	}
+	if tile.VerticalFlip {
+		matrix = matrix.ScaledXY(pixel.ZV, pixel.V(1, -1))
+	}
+
+	return matrix.Moved(corner.Add(size.Scaled(0.5)))
+}
+
+// sprite will return the sprite for the tile, nil if its image can't be
+// loaded.
+func (r *TileRenderer) sprite(ts *tiled.Tileset, id uint32) *pixel.Sprite {
+	if sprite, ok := r.sprites[ts][id]; ok {
+		return sprite
+	}
+	if r.sprites[ts] == nil {
+		r.sprites[ts] = make(map[uint32]*pixel.Sprite)
+	}
+
+	var sprite *pixel.Sprite
+	if ts.Image != nil {
+		// Tileset images are sliced top down, pictures go bottom up.
+		pic, err := r.picture(ts.GetFileFullPath(ts.Image.Source))
+		if err == nil {
+			rect := ts.GetTileRect(id)
+			h := pic.Bounds().H()
+			sprite = pixel.NewSprite(pic, pixel.R(float64(rect.Min.X), h-float64(rect.Max.Y), float64(rect.Max.X), h-float64(rect.Min.Y)))
+		}
+	} else {
+		// Collections of images have a picture for every tile.
+		for _, t := range ts.Tiles {
+			if t.ID == id && t.Image != nil {
+				if pic, err := r.picture(ts.GetFileFullPath(t.Image.Source)); err == nil {
+					sprite = pixel.NewSprite(pic, pic.Bounds())
+				}
+			}
+		}
+	}
+
+	r.sprites[ts][id] = sprite
+	return sprite
+}
+
+// picture will load the image file, only the once.
+func (r *TileRenderer) picture(file string) (pixel.Picture, error) {
+	if pic, ok := r.pictures[file]; ok {
+		return pic, nil
+	}
+
+	pic, err := LoadImage(file)
+	if err != nil {
+		return nil, err
+	}
+	r.pictures[file] = pic
+	return pic, nil
+}
+
+// evict will drop the chunks drawn least recently until we are within our
+// limit. Chunks from the latest draw are always kept.
+func (r *TileRenderer) evict() {
+	for len(r.chunks) > r.MaxChunks {
+		var oldest *tileChunkKey
+		for key, chunk := range r.chunks {
+			if chunk.drawn < r.drawn && (oldest == nil || chunk.drawn < r.chunks[*oldest].drawn) {
+				k := key
+				oldest = &k
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(r.chunks, *oldest)
+	}
+}
+
+// minInt will return the smaller value.
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt will return the larger value.
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// clampInt will keep the value between min and max.
+func clampInt(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
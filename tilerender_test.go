@@ -0,0 +1,229 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+// countTarget is a target that counts the triangles drawn onto it.
+type countTarget struct {
+	triangles int
+}
+
+func (c *countTarget) MakeTriangles(t pixel.Triangles) pixel.TargetTriangles {
+	data := pixel.MakeTrianglesData(t.Len())
+	data.Update(t)
+	return &countTriangles{TrianglesData: data, target: c}
+}
+
+func (c *countTarget) MakePicture(p pixel.Picture) pixel.TargetPicture {
+	return &countPicture{Picture: p, target: c}
+}
+
+type countTriangles struct {
+	*pixel.TrianglesData
+	target *countTarget
+}
+
+func (c *countTriangles) Draw() {
+	c.target.triangles += c.Len() / 3
+}
+
+type countPicture struct {
+	pixel.Picture
+	target *countTarget
+}
+
+func (c *countPicture) Draw(t pixel.TargetTriangles) {
+	c.target.triangles += t.Len() / 3
+}
+
+func TestTileRenderer(t *testing.T) {
+	m, err := NewMap("test_assets/maps/bigtest.tmx")
+	assert.NoError(t, err)
+	assert.NoError(t, m.Tiles.LoadTilesets())
+
+	// A 480x480 camera in the corner of a 40x40 map sees one 16x16 chunk
+	// across and two up, as the map is drawn from the top.
+	camera := pixel.R(0, 0, 480, 480)
+	assert.Len(t, m.Tiles.visibleChunks(camera, 0), 2)
+	assert.Len(t, m.Tiles.visibleChunks(camera.Moved(pixel.V(-2000, 0)), 0), 0, "Nothing is drawn off the map.")
+
+	target := &countTarget{}
+	m.Tiles.DrawLayer(target, camera, 0, pixel.Alpha(1))
+	assert.Equal(t, 2, m.Tiles.Cached(), "Drawn chunks should be cached.")
+	assert.True(t, target.triangles > 0, "Tiles should be drawn.")
+	assert.Equal(t, 0, target.triangles%2, "Each tile is two triangles.")
+
+	// Drawing again uses the cache.
+	drawn := target.triangles
+	m.Tiles.DrawLayer(target, camera, 0, pixel.Alpha(1))
+	assert.Equal(t, 2*drawn, target.triangles)
+	assert.Equal(t, 2, m.Tiles.Cached())
+
+	// Old chunks are dropped once we have too many.
+	m.Tiles.MaxChunks = 1
+	m.Tiles.DrawLayer(target, pixel.R(512, 0, 768, 256), 0, pixel.Alpha(1))
+	assert.Equal(t, 1, m.Tiles.Cached(), "We should stay within our limit.")
+	_, ok := m.Tiles.chunks[tileChunkKey{0, 1, 2}]
+	assert.True(t, ok, "The chunk we can see should be kept.")
+
+	m.Tiles.Clear()
+	assert.Equal(t, 0, m.Tiles.Cached())
+}
+
+func TestTileMatrix(t *testing.T) {
+	m, err := NewMap("test_assets/maps/groups.tmx")
+	assert.NoError(t, err)
+	layer := m.Src.Layers[0]
+	size := pixel.V(32, 32)
+
+	// Tiles sit in their cell, flipped around their centre.
+	tile := *layer.Tiles[0]
+	matrix := m.Tiles.tileMatrix(layer, 0, 0, &tile, size)
+	assert.Equal(t, pixel.V(16, 112), matrix.Project(pixel.ZV))
+	assert.Equal(t, pixel.V(26, 117), matrix.Project(pixel.V(10, 5)))
+
+	tile.HorizontalFlip = true
+	matrix = m.Tiles.tileMatrix(layer, 0, 0, &tile, size)
+	assert.Equal(t, pixel.V(6, 117), matrix.Project(pixel.V(10, 5)))
+
+	tile.HorizontalFlip, tile.DiagonalFlip = false, true
+	matrix = m.Tiles.tileMatrix(layer, 0, 0, &tile, size)
+	assert.Equal(t, pixel.V(11, 102), matrix.Project(pixel.V(10, 5)), "Diagonal flips swap across and up.")
+}
+
+func TestInfiniteMap(t *testing.T) {
+	m, err := NewMap("test_assets/maps/infinite.tmx")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, m.Src.Width, "The map should cover every chunk.")
+	assert.Equal(t, 8, m.Src.Height)
+	assert.Equal(t, 4, m.Origin.X)
+	assert.Equal(t, 4, m.Origin.Y)
+
+	assert.Equal(t, uint32(593), m.Tile(0, 0, 4).ID, "The left chunk starts the map.")
+	assert.Equal(t, uint32(595), m.Tile(0, 7, 7).ID)
+	assert.True(t, m.Tile(0, 0, 0).IsNil(), "Cells without a chunk are empty.")
+	assert.Equal(t, uint32(593), m.Tile(1, 5, 1).ID, "Compressed chunks should be read.")
+	assert.True(t, m.Tile(1, 7, 3).HorizontalFlip, "Flips should be kept.")
+
+	// Objects move along with the tiles.
+	obj := m.FindObject("Marker")
+	if assert.NotNil(t, obj) {
+		assert.Equal(t, 144.0, obj.X)
+		assert.Equal(t, 144.0, obj.Y)
+	}
+}
+
+func TestInfiniteMapSparse(t *testing.T) {
+	m, err := NewMap("test_assets/maps/faraway.tmx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 128004, m.Src.Width, "The map should cover every chunk.")
+	assert.Equal(t, 128004, m.Src.Height)
+
+	// Only the chunks are held, not the space between them.
+	assert.Nil(t, m.Src.Layers[0].Tiles)
+	assert.Len(t, m.chunks[0].Tiles, 2)
+	assert.Equal(t, uint32(593), m.Tile(0, 0, 0).ID)
+	assert.Equal(t, uint32(595), m.Tile(0, 128003, 128003).ID)
+	assert.True(t, m.Tile(0, 64000, 64000).IsNil())
+	assert.True(t, m.Tile(0, -1, 0).IsNil(), "Cells off the map are empty.")
+
+	// Chunks are drawn like any other tiles.
+	m.Tiles.pictures[m.Src.Tilesets[0].GetFileFullPath(m.Src.Tilesets[0].Image.Source)] = pixel.MakePictureData(pixel.R(0, 0, 2048, 3040))
+	target := &countTarget{}
+	m.Tiles.DrawLayer(target, pixel.R(0, m.Size.Y-128, 128, m.Size.Y), 0, pixel.Alpha(1))
+	assert.Equal(t, 2, target.triangles, "The top left tile should be drawn.")
+}
+
+func BenchmarkMapPrerender(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m, _ := NewMap("test_assets/maps/bigtest.tmx")
+		m.PreRender()
+		bytes := 0
+		for _, img := range m.Img {
+			bytes += len(img.Pix) * 4
+		}
+		b.ReportMetric(float64(bytes), "texturebytes/op")
+	}
+}
+
+func BenchmarkTileRendererLoad(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m, _ := NewMap("test_assets/maps/bigtest.tmx")
+		m.Tiles.LoadTilesets()
+		bytes := 0
+		for _, pic := range m.Tiles.pictures {
+			bytes += len(pic.(*pixel.PictureData).Pix) * 4
+		}
+		b.ReportMetric(float64(bytes), "texturebytes/op")
+	}
+}
+
+func BenchmarkTileRendererFrame(b *testing.B) {
+	m, _ := NewMap("test_assets/maps/bigtest.tmx")
+	m.Tiles.LoadTilesets()
+	target := &countTarget{}
+	camera := pixel.R(0, 0, 640, 480)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Pan across the map, building chunks as they come into view.
+		moved := camera.Moved(pixel.V(float64(i%600), float64(i%800)))
+		m.Tiles.Draw(target, moved, false)
+		m.Tiles.Draw(target, moved, true)
+	}
+	b.ReportMetric(float64(m.Tiles.Cached()), "chunks")
+}
+
+func BenchmarkTileRendererColdFrame(b *testing.B) {
+	m, _ := NewMap("test_assets/maps/bigtest.tmx")
+	m.Tiles.LoadTilesets()
+	target := &countTarget{}
+	camera := pixel.R(0, 0, 640, 480)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Tiles.Clear()
+		m.Tiles.Draw(target, camera, false)
+	}
+}
+
+func TestAnimatedTiles(t *testing.T) {
+	m, err := NewMap("test_assets/maps/animated.tmx")
+	assert.NoError(t, err)
+	animation := m.Tiles.animations[m.Src.Tilesets[0]][593]
+	if !assert.NotNil(t, animation, "The water tile should be animated.") {
+		return
+	}
+
+	assert.Equal(t, uint32(593), animation.frameAt(0.05))
+	assert.Equal(t, uint32(594), animation.frameAt(0.15))
+	assert.Equal(t, uint32(593), animation.frameAt(0.35), "Animations should loop.")
+
+	// Only the animated tiles are left out of the chunk batch.
+	target := &countTarget{}
+	camera := pixel.R(0, 0, 64, 64)
+	m.Tiles.DrawLayer(target, camera, 0, pixel.Alpha(1))
+	chunk := m.Tiles.chunks[tileChunkKey{0, 0, 0}]
+	assert.Len(t, chunk.animated, 2)
+	assert.Equal(t, 8, target.triangles, "All four tiles should be drawn.")
+	assert.Equal(t, []uint32{593, 593}, chunk.frames)
+
+	// The animated tiles are batched again once the frame changes.
+	batches := chunk.animatedBatches
+	m.Tiles.DrawLayer(target, camera, 0, pixel.Alpha(1))
+	assert.Equal(t, batches, chunk.animatedBatches, "Nothing has changed yet.")
+	m.Tiles.Time = 0.15
+	m.Tiles.DrawLayer(target, camera, 0, pixel.Alpha(1))
+	assert.Equal(t, []uint32{594, 594}, chunk.frames)
+	assert.NotEqual(t, batches, chunk.animatedBatches)
+}
@@ -0,0 +1,435 @@
+package gamesys
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// tmxChunk is one chunk of an infinite map tile layer.
+type tmxChunk struct {
+	X      int    `xml:"x,attr"`
+	Y      int    `xml:"y,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	Raw    []byte `xml:",chardata"`
+	Tiles  []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+// tmxData is the tile data of an infinite map tile layer.
+type tmxData struct {
+	Encoding    string     `xml:"encoding,attr"`
+	Compression string     `xml:"compression,attr"`
+	Chunks      []tmxChunk `xml:"chunk"`
+}
+
+// tmxHeader is what we read from the map element ourselves, either because
+// go-tiled can't or because the map is rewritten before go-tiled sees it.
+type tmxHeader struct {
+	// Infinite maps are stored in chunks.
+	Infinite bool
+
+	// StaggerAxis and StaggerIndex are the Tiled stagger settings, which
+	// go-tiled expects to be numbers.
+	StaggerAxis  string
+	StaggerIndex string
+
+	// Origin is the column and row of the tile Tiled places at 0,0.
+	Origin image.Point
+
+	// Chunks are the tiles of each tile layer of an infinite map.
+	Chunks []*tileChunks
+}
+
+// tileChunks are the tiles of an infinite map layer, kept in the chunks
+// Tiled stores them in so only the parts of the map with tiles are held.
+type tileChunks struct {
+	// Size is how many columns and rows each chunk covers.
+	Size image.Point
+
+	// Tiles are the tiles of each chunk row by row, by chunk column and row.
+	Tiles map[image.Point][]*tiled.LayerTile
+
+	// gids are the tile ids read, until the tilesets are loaded.
+	gids map[image.Point][]uint32
+}
+
+// tile will return the tile at the column and row, the nil tile if no chunk
+// covers it.
+func (c *tileChunks) tile(column int, row int) *tiled.LayerTile {
+	if len(c.Tiles) == 0 {
+		return tiled.NilLayerTile
+	}
+	tiles, ok := c.Tiles[image.Pt(column/c.Size.X, row/c.Size.Y)]
+	if !ok {
+		return tiled.NilLayerTile
+	}
+	return tiles[(row%c.Size.Y)*c.Size.X+column%c.Size.X]
+}
+
+// keys will return the chunks held, row by row.
+func (c *tileChunks) keys() []image.Point {
+	keys := make([]image.Point, 0, len(c.Tiles))
+	for key := range c.Tiles {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Y != keys[j].Y {
+			return keys[i].Y < keys[j].Y
+		}
+		return keys[i].X < keys[j].X
+	})
+	return keys
+}
+
+// loadTiledMap will load a Tiled map file. Infinite maps are turned into
+// regular maps covering all of their chunks, as go-tiled can't read chunked
+// layers. The chunks themselves are kept in the header rather than filling
+// in the whole map, so memory follows the tiles placed and not the space
+// between them. Chunks can sit left of or above the Tiled origin, so the
+// whole map is shifted to start at the top left chunk, objects included,
+// and the origin tile is kept in the header. Stagger settings are taken out
+// of the map, as go-tiled can't read them, and kept in the header too.
+func loadTiledMap(file string) (*tiled.Map, tmxHeader, error) {
+	header := tmxHeader{}
+	source, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, header, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, header, err
+	}
+
+	// Regular maps can be read as they are.
+	bounds, err := readHeader(source, &header)
+	if err != nil {
+		return nil, header, err
+	}
+	if !header.Infinite && header.StaggerAxis == "" && header.StaggerIndex == "" {
+		m, err := tiled.LoadFromReader(dir, bytes.NewReader(source))
+		return m, header, err
+	}
+
+	rewritten, err := rewriteMap(source, &header, bounds)
+	if err != nil {
+		return nil, header, err
+	}
+
+	m, err := tiled.LoadFromReader(dir, bytes.NewReader(rewritten))
+	if err != nil || !header.Infinite {
+		return m, header, err
+	}
+
+	// Now the tilesets are loaded we can find the chunk tiles, leaving the
+	// layers themselves empty.
+	m.Width, m.Height = bounds.Dx(), bounds.Dy()
+	for i, layer := range m.Layers {
+		layer.Tiles = nil
+		chunks := header.Chunks[i]
+		chunks.Tiles = make(map[image.Point][]*tiled.LayerTile)
+		for key, gids := range chunks.gids {
+			tiles := make([]*tiled.LayerTile, len(gids))
+			for j, gid := range gids {
+				tiles[j], err = m.TileGIDToTile(gid)
+				if err != nil {
+					return nil, header, err
+				}
+			}
+			chunks.Tiles[key] = tiles
+		}
+		chunks.gids = nil
+	}
+	return m, header, nil
+}
+
+// readHeader will read the map element into the header, and find the tiles
+// covered by the chunks of an infinite map.
+func readHeader(source []byte, header *tmxHeader) (image.Rectangle, error) {
+	bounds := image.Rectangle{}
+
+	decoder := xml.NewDecoder(bytes.NewReader(source))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return bounds, nil
+		}
+		if err != nil {
+			return bounds, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "map":
+			header.Infinite = attr(start, "infinite") == "1"
+			header.StaggerAxis = attr(start, "staggeraxis")
+			header.StaggerIndex = attr(start, "staggerindex")
+			if !header.Infinite {
+				return bounds, nil
+			}
+		case "chunk":
+			x, y := int(StrFloat(attr(start, "x"))), int(StrFloat(attr(start, "y")))
+			w, h := int(StrFloat(attr(start, "width"))), int(StrFloat(attr(start, "height")))
+			bounds = bounds.Union(image.Rect(x, y, x+w, y+h))
+		}
+	}
+}
+
+// rewriteMap will rewrite the map so go-tiled can read it. Stagger settings
+// are dropped, and infinite maps become regular maps of a single empty tile,
+// their chunks going in the header. Layers in groups aren't drawn, so their
+// chunks are dropped. Objects outside of tilesets are moved along with the
+// tiles.
+func rewriteMap(source []byte, header *tmxHeader, bounds image.Rectangle) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(source))
+	output := &bytes.Buffer{}
+	encoder := xml.NewEncoder(output)
+
+	offset := pixel.ZV
+	inTileset, inGroup := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "map":
+				removeAttr(&t, "staggeraxis")
+				removeAttr(&t, "staggerindex")
+				if header.Infinite {
+					header.Origin = image.Pt(-bounds.Min.X, -bounds.Min.Y)
+					offset = infiniteOffset(t, header, bounds.Min)
+					setAttr(&t, "width", "1")
+					setAttr(&t, "height", "1")
+					setAttr(&t, "infinite", "0")
+				}
+			case "tileset":
+				inTileset++
+			case "group":
+				inGroup++
+			case "object":
+				if inTileset == 0 && header.Infinite {
+					setAttr(&t, "x", strconv.FormatFloat(StrFloat(attr(t, "x"))-offset.X, 'f', -1, 64))
+					setAttr(&t, "y", strconv.FormatFloat(StrFloat(attr(t, "y"))-offset.Y, 'f', -1, 64))
+				}
+			case "data":
+				if !header.Infinite {
+					break
+				}
+				data := &tmxData{}
+				err = decoder.DecodeElement(data, &t)
+				if err != nil {
+					return nil, err
+				}
+				if inGroup == 0 {
+					chunks, err := readChunks(data, bounds.Min)
+					if err != nil {
+						return nil, err
+					}
+					header.Chunks = append(header.Chunks, chunks)
+				}
+				err = writeEmptyData(encoder)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			token = t
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "tileset":
+				inTileset--
+			case "group":
+				inGroup--
+			}
+		}
+
+		err = encoder.EncodeToken(xml.CopyToken(token))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := encoder.Flush()
+	return output.Bytes(), err
+}
+
+// infiniteOffset will work out how far objects move when an infinite map
+// is shifted to start at the given tile. Moving staggered maps by an odd
+// number of tiles along the staggered axis swaps which tiles are shifted.
+func infiniteOffset(start xml.StartElement, header *tmxHeader, min image.Point) pixel.Vec {
+	grid := &MapGrid{
+		Orientation: attr(start, "orientation"),
+		TileSize:    pixel.V(StrFloat(attr(start, "tilewidth")), StrFloat(attr(start, "tileheight"))),
+		StaggerX:    header.StaggerAxis == "x",
+		HexSide:     StrFloat(attr(start, "hexsidelength")),
+	}
+
+	moved := min.Y
+	if grid.StaggerX {
+		moved = min.X
+	}
+	if grid.staggered() && moved&1 == 1 {
+		if header.StaggerIndex == "even" {
+			header.StaggerIndex = "odd"
+		} else {
+			header.StaggerIndex = "even"
+		}
+	}
+
+	return grid.TileOffset(min.X, min.Y)
+}
+
+// readChunks will read the chunks of the layer data, keyed by where they
+// sit once the map starts at the given tile. Tiled lines its chunks up on a
+// grid of the chunk size, so each chunk is found from the tile column and
+// row alone.
+func readChunks(data *tmxData, min image.Point) (*tileChunks, error) {
+	chunks := &tileChunks{gids: make(map[image.Point][]uint32)}
+	for _, c := range data.Chunks {
+		if chunks.Size == (image.Point{}) {
+			chunks.Size = image.Pt(c.Width, c.Height)
+		}
+		x, y := c.X-min.X, c.Y-min.Y
+		if c.Width != chunks.Size.X || c.Height != chunks.Size.Y || x%c.Width != 0 || y%c.Height != 0 {
+			return nil, errors.New("loadmap: chunk at " + strconv.Itoa(c.X) + "," + strconv.Itoa(c.Y) + " is off the chunk grid")
+		}
+
+		gids, err := decodeChunk(data, c)
+		if err != nil {
+			return nil, err
+		}
+		chunks.gids[image.Pt(x/c.Width, y/c.Height)] = gids
+	}
+	return chunks, nil
+}
+
+// writeEmptyData will write layer data holding a single empty tile.
+func writeEmptyData(encoder *xml.Encoder) error {
+	start := xml.StartElement{Name: xml.Name{Local: "data"}, Attr: []xml.Attr{{Name: xml.Name{Local: "encoding"}, Value: "csv"}}}
+	err := encoder.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	err = encoder.EncodeToken(xml.CharData("0"))
+	if err != nil {
+		return err
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// decodeChunk will read the tile ids of a chunk, in csv, base64 or xml.
+func decodeChunk(data *tmxData, c tmxChunk) ([]uint32, error) {
+	gids := make([]uint32, 0, c.Width*c.Height)
+
+	switch data.Encoding {
+	case "csv":
+		for _, value := range strings.Split(string(c.Raw), ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(c.Raw)))
+		if err != nil {
+			return nil, err
+		}
+
+		var reader io.Reader = bytes.NewReader(raw)
+		switch data.Compression {
+		case "gzip":
+			reader, err = gzip.NewReader(reader)
+		case "zlib":
+			reader, err = zlib.NewReader(reader)
+		case "":
+		default:
+			err = errors.New("loadmap: unsupported compression " + data.Compression)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+3 < len(raw); i += 4 {
+			gids = append(gids, uint32(raw[i])|uint32(raw[i+1])<<8|uint32(raw[i+2])<<16|uint32(raw[i+3])<<24)
+		}
+	case "":
+		for _, t := range c.Tiles {
+			gids = append(gids, t.GID)
+		}
+	default:
+		return nil, errors.New("loadmap: unsupported encoding " + data.Encoding)
+	}
+
+	if len(gids) != c.Width*c.Height {
+		return nil, errors.New("loadmap: chunk at " + strconv.Itoa(c.X) + "," + strconv.Itoa(c.Y) + " has the wrong number of tiles")
+	}
+	return gids, nil
+}
+
+// attr will return the value of the named attribute, empty if not set.
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// removeAttr will take the named attribute out, if it is there.
+func removeAttr(start *xml.StartElement, name string) {
+	attrs := make([]xml.Attr, 0, len(start.Attr))
+	for _, a := range start.Attr {
+		if a.Name.Local != name {
+			attrs = append(attrs, a)
+		}
+	}
+	start.Attr = attrs
+}
+
+// setAttr will set the named attribute, adding it if it isn't there.
+func setAttr(start *xml.StartElement, name string, value string) {
+	attrs := make([]xml.Attr, len(start.Attr))
+	copy(attrs, start.Attr)
+	start.Attr = attrs
+
+	for i, a := range start.Attr {
+		if a.Name.Local == name {
+			start.Attr[i].Value = value
+			return
+		}
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
@@ -0,0 +1,82 @@
+package gamesys
+
+import (
+	"image/color"
+
+	"github.com/faiface/pixel"
+)
+
+// Matrix will return the matrix that places the actor on the map, applying
+// the scale, flips and rotation around the origin. Attached actors are placed
+// at their offset, then transformed along with their parent.
+func (a *Actor) Matrix() pixel.Matrix {
+	scale := a.Scale()
+	if a.FlipX {
+		scale.X = -scale.X
+	}
+	if a.FlipY {
+		scale.Y = -scale.Y
+	}
+
+	local := pixel.IM.ScaledXY(a.Origin, scale).Rotated(a.Origin, a.Rotation*DegRad)
+	if a.Parent != nil {
+		return local.Moved(a.Offset).Chained(a.Parent.Matrix())
+	}
+	return local.Moved(a.Position)
+}
+
+// ColorMask will return the color the actor is drawn with, being the tint
+// faded by the alpha, and mixed with the parent color.
+func (a *Actor) ColorMask() pixel.RGBA {
+	tint := a.Tint
+	if tint == (color.RGBA{}) {
+		tint = color.RGBA{255, 255, 255, 255}
+	}
+	mask := pixel.ToRGBA(tint).Scaled(a.Alpha())
+	if a.Parent != nil {
+		mask = mask.Mul(a.Parent.ColorMask())
+	}
+	return mask
+}
+
+// Alpha will return how opaque the actor is, from 0 to 1.
+func (a *Actor) Alpha() float64 {
+	return 1 - a.Transparency
+}
+
+// SetAlpha will set how opaque the actor is, from 0 to 1.
+func (a *Actor) SetAlpha(alpha float64) {
+	a.Transparency = 1 - alpha
+}
+
+// Scale will return the size of the actor, with 1 being the normal size.
+func (a *Actor) Scale() pixel.Vec {
+	return a.Growth.Add(pixel.V(1, 1))
+}
+
+// SetScale will resize the actor, keeping the clip up to date.
+func (a *Actor) SetScale(scale pixel.Vec) {
+	a.Growth = scale.Sub(pixel.V(1, 1))
+	a.SetClip()
+}
+
+// SetRotation will rotate the actor to the angle in degrees, keeping the
+// clip up to date.
+func (a *Actor) SetRotation(rotation float64) {
+	a.Rotation = rotation
+	a.SetClip()
+}
+
+// SetOrigin will set the point the actor scales and rotates around, keeping
+// the clip up to date.
+func (a *Actor) SetOrigin(origin pixel.Vec) {
+	a.Origin = origin
+	a.SetClip()
+}
+
+// Flip will mirror the actor across and/or up and down.
+func (a *Actor) Flip(x bool, y bool) {
+	a.FlipX = x
+	a.FlipY = y
+	a.SetClip()
+}
@@ -0,0 +1,99 @@
+package gamesys
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorTransform(t *testing.T) {
+	src := pixel.MakePictureData(pixel.R(0, 0, 20, 10))
+	actor := &Actor{Src: src, Position: pixel.V(100, 100)}
+	actor.Render()
+	assert.Equal(t, pixel.RGBA{R: 1, G: 1, B: 1, A: 1}, actor.ColorMask(), "A new actor should be opaque.")
+	assert.Equal(t, pixel.R(90, 95, 110, 105), actor.Clip, "Our clip should be centred on us.")
+
+	actor.SetScale(pixel.V(2, 2))
+	assert.Equal(t, pixel.R(80, 90, 120, 110), actor.Clip, "Scaling should grow our clip.")
+
+	actor.SetScale(pixel.V(1, 1))
+	actor.SetRotation(90)
+	assert.InDelta(t, 10, actor.Clip.W(), 1e-9, "Rotating should turn our clip.")
+	assert.InDelta(t, 20, actor.Clip.H(), 1e-9, "Rotating should turn our clip.")
+
+	// Rotating around our left edge swings us up.
+	actor.SetOrigin(pixel.V(-10, 0))
+	assert.InDelta(t, 100, actor.Clip.Min.Y, 1e-9, "We should rotate around our origin.")
+
+	actor.Tint = color.RGBA{255, 0, 0, 255}
+	actor.SetAlpha(0.5)
+	assert.Equal(t, pixel.RGBA{R: 0.5, A: 0.5}, actor.ColorMask(), "Tint should be faded by our alpha.")
+}
+
+func TestTweenActor(t *testing.T) {
+	e := &Engine{Listeners: make(map[string][]*Listener), Dt: 0.5}
+	src := pixel.MakePictureData(pixel.R(0, 0, 20, 10))
+	actor := &Actor{Src: src}
+	actor.Render()
+
+	_, err := e.TweenActor(actor, "wobble", []float64{1}, 1, EaseLinear, false)
+	assert.Error(t, err, "We should reject unknown properties.")
+	_, err = e.TweenActor(actor, "tint", []float64{1}, 1, EaseLinear, false)
+	assert.Error(t, err, "We should reject the wrong number of values.")
+
+	finished := 0
+	e.Listen("tween", "test", func(ev *Event) { finished++ })
+
+	_, err = e.TweenActor(actor, "alpha", []float64{0}, 1, EaseLinear, false)
+	assert.NoError(t, err)
+	_, err = e.TweenActor(actor, "scale", []float64{2}, 1, EaseLinear, true)
+	assert.NoError(t, err)
+
+	e.ProcessTweens()
+	assert.Equal(t, 0.5, actor.Alpha(), "We should be half way.")
+	assert.Equal(t, pixel.V(2, 2), actor.Scale(), "Yoyos reach the end half way.")
+	assert.Equal(t, 40.0, actor.Clip.W(), "Our clip should follow the scale.")
+
+	e.ProcessTweens()
+	assert.Equal(t, 0.0, actor.Alpha(), "We should be done.")
+	assert.Equal(t, pixel.V(1, 1), actor.Scale(), "Yoyos come back.")
+	assert.Equal(t, 2, finished, "Both tweens should report finishing.")
+	assert.Empty(t, e.Tweens, "Finished tweens are removed.")
+
+	// Shrinking away stays gone.
+	_, err = e.TweenActor(actor, "scale", []float64{0}, 1, EaseLinear, false)
+	assert.NoError(t, err)
+	e.ProcessTweens()
+	assert.Equal(t, pixel.V(0.5, 0.5), actor.Scale(), "We should be half way.")
+	e.ProcessTweens()
+	assert.Equal(t, pixel.ZV, actor.Scale(), "We should shrink to nothing.")
+	assert.Equal(t, 0.0, actor.Clip.W(), "Our clip should shrink to nothing.")
+}
+
+func TestActorTransformActions(t *testing.T) {
+	hero := newTestActor("hero", pixel.V(100, 100))
+	actionScene("transforms", hero)
+	defer delete(testEngine.Scenes, "transforms")
+	defer testEngine.StopTween(hero, "rotation")
+
+	assert.NoError(t, runAction("ActorScale", "transforms", "hero", "2"))
+	assert.Equal(t, pixel.V(2, 2), hero.Scale(), "A single scale should be used both ways.")
+	assert.NoError(t, runAction("ActorRotation", "transforms", "hero", "90"))
+	assert.Equal(t, 90.0, hero.Rotation)
+	assert.NoError(t, runAction("ActorOrigin", "transforms", "hero", "1", "2"))
+	assert.Equal(t, pixel.V(1, 2), hero.Origin)
+	assert.NoError(t, runAction("ActorFlip", "transforms", "hero", "true", "false"))
+	assert.True(t, hero.FlipX)
+	assert.NoError(t, runAction("ActorTint", "transforms", "hero", "255", "0", "0"))
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, hero.Tint)
+	assert.NoError(t, runAction("ActorAlpha", "transforms", "hero", "0.5"))
+	assert.Equal(t, 0.5, hero.Alpha())
+	assert.NoError(t, runAction("TweenActor", "transforms", "hero", "rotation", "1", "linear", "false", "180"))
+
+	// Unknown actors are errors rather than crashes.
+	for _, action := range []string{"ActorScale", "ActorRotation", "ActorOrigin", "ActorFlip", "ActorTint", "ActorAlpha", "TweenActor"} {
+		assert.Error(t, runAction(action, "transforms", "nobody", "1", "1", "1", "1", "1"), "%s should reject unknown actors.", action)
+	}
+}
@@ -0,0 +1,78 @@
+package gamesys
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Transition covers a change of scenery, such as fading out and back in
+// while walking through a door. The action runs halfway, when the screen is
+// fully covered. When done a "transition" event is emitted, with the kind
+// in the data.
+type Transition struct {
+	// Kind is the kind of transition, being "fade". Anything else cuts
+	// straight over halfway through.
+	Kind string
+
+	// Duration is how long the whole transition takes, in seconds.
+	Duration float64
+
+	// Elapsed is how long the transition has been running.
+	Elapsed float64
+
+	// Action runs halfway through the transition.
+	Action func()
+	acted  bool
+}
+
+// StartTransition will start a transition, replacing any already running.
+// The action runs halfway through.
+func (e *Engine) StartTransition(kind string, duration float64, action func()) {
+	e.Transition = &Transition{Kind: kind, Duration: duration, Action: action}
+}
+
+// Update will move the transition along, running the action once halfway.
+// It indicates if the transition is finished.
+func (t *Transition) Update(dt float64) bool {
+	t.Elapsed += dt
+	if !t.acted && t.Elapsed >= t.Duration/2 {
+		t.acted = true
+		if t.Action != nil {
+			t.Action()
+		}
+	}
+	return t.Elapsed >= t.Duration
+}
+
+// Progress will return how far through the transition we are, from 0 to 1.
+func (t *Transition) Progress() float64 {
+	if t.Duration <= 0 {
+		return 1
+	}
+	return math.Min(t.Elapsed/t.Duration, 1)
+}
+
+// Mask will return the colour mask to draw the scene with, nil when the
+// scene is drawn as it is.
+func (t *Transition) Mask() color.Color {
+	if t == nil || t.Kind != "fade" {
+		return nil
+	}
+
+	// Darken until halfway, then brighten again.
+	light := math.Abs(1 - 2*t.Progress())
+	return pixel.RGB(light, light, light)
+}
+
+// ProcessTransition will move along the running transition, if any.
+func (e *Engine) ProcessTransition() {
+	t := e.Transition
+	if t == nil || !t.Update(e.Dt) {
+		return
+	}
+
+	e.Transition = nil
+	e.Emit(&Event{Name: "transition", Data: map[string]interface{}{"kind": t.Kind}})
+}
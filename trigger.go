@@ -0,0 +1,64 @@
+package gamesys
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// TriggerArea is an area of the map that reacts to actors entering and
+// leaving it. Trigger areas come from Tiled objects with the trigger role,
+// usually by giving them the "Trigger" type. Actors stepping in emit a
+// "triggerenter" event and stepping out a "triggerexit" event, both with the
+// area as "trigger" in the event data.
+type TriggerArea struct {
+	// Name is the map object name.
+	Name string
+
+	// Area is where the trigger is on the map.
+	Area pixel.Rect
+
+	// Properties are the custom properties of the map object.
+	Properties Properties
+}
+
+// NewTriggerArea will read a trigger area from a Tiled map object.
+func NewTriggerArea(obj *tiled.Object, area pixel.Rect) *TriggerArea {
+	return &TriggerArea{Name: obj.Name, Area: area, Properties: PropertiesFromTiled(obj.Properties)}
+}
+
+// Covers will indicate if the actor is inside the area. Point objects
+// have no size, so need to be inside the actor.
+func (t *TriggerArea) Covers(actor *Actor) bool {
+	if t.Area.Area() == 0 {
+		return actor.Clip.Contains(t.Area.Min)
+	}
+	return actor.Clip.Intersects(t.Area)
+}
+
+// ProcessTriggers will emit the trigger events for the scene actors that
+// stepped into or out of the map triggers since the last cycle.
+func (s *Scene) ProcessTriggers() {
+	if s.MapData == nil {
+		return
+	}
+
+	for _, a := range s.Actors {
+		inside := make(map[*TriggerArea]bool)
+		for _, t := range s.MapData.Triggers {
+			if !t.Covers(a) {
+				continue
+			}
+			inside[t] = true
+			if !a.triggers[t] {
+				s.Engine.Emit(&Event{Name: "triggerenter", Actor: a, Data: map[string]interface{}{"trigger": t}})
+			}
+		}
+
+		for t := range a.triggers {
+			if !inside[t] {
+				s.Engine.Emit(&Event{Name: "triggerexit", Actor: a, Data: map[string]interface{}{"trigger": t}})
+			}
+		}
+		a.triggers = inside
+	}
+}
@@ -0,0 +1,200 @@
+package gamesys
+
+import (
+	"errors"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Ease shapes the progress of a tween, taking and returning a value from 0
+// to 1.
+type Ease func(float64) float64
+
+// EaseLinear moves at a steady pace.
+func EaseLinear(t float64) float64 {
+	return t
+}
+
+// EaseIn starts slow and speeds up.
+func EaseIn(t float64) float64 {
+	return t * t
+}
+
+// EaseOut starts fast and slows down.
+func EaseOut(t float64) float64 {
+	return t * (2 - t)
+}
+
+// EaseInOut starts and finishes slow.
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+// ParseEase will return the ease with the name, being linear, in, out or
+// inout. Anything else is linear.
+func ParseEase(name string) Ease {
+	switch name {
+	case "in":
+		return EaseIn
+	case "out":
+		return EaseOut
+	case "inout":
+		return EaseInOut
+	}
+	return EaseLinear
+}
+
+// Tween smoothly changes an actor transform over time, such as fading out a
+// ghost or shrinking a pickup. When done a "tween" event is emitted for the
+// actor, with the property in the data.
+type Tween struct {
+	// Actor is the actor being changed.
+	Actor *Actor
+
+	// Property is what is being changed, being scale, rotation, alpha or
+	// tint.
+	Property string
+
+	// From and To are the values we start and finish on.
+	From []float64
+	To   []float64
+
+	// Duration is how long the tween takes, in seconds.
+	Duration float64
+
+	// Elapsed is how long the tween has been running, in seconds.
+	Elapsed float64
+
+	// Ease shapes how we get there.
+	Ease Ease
+
+	// Yoyo will head back to the start once finished, like a damage flash.
+	Yoyo bool
+}
+
+// Update will move the tween along by the time step, applying the new value
+// to the actor. It returns true once finished.
+func (t *Tween) Update(dt float64) bool {
+	t.Elapsed += dt
+
+	// Work out how far along we are, heading back for yoyos.
+	progress := 1.0
+	if t.Duration > 0 {
+		progress = math.Min(t.Elapsed/t.Duration, 1)
+	}
+	if t.Yoyo {
+		progress = 1 - math.Abs(1-progress*2)
+	}
+	if t.Ease != nil {
+		progress = t.Ease(progress)
+	}
+
+	values := make([]float64, len(t.From))
+	for i := range values {
+		values[i] = t.From[i] + (t.To[i]-t.From[i])*progress
+	}
+	t.Actor.setTweenValues(t.Property, values)
+
+	return t.Elapsed >= t.Duration
+}
+
+// TweenActor will start changing the actor property towards the values,
+// replacing any tween already running on it. The values needed are:
+//
+//	scale: x and y, or one for both
+//	rotation: degrees
+//	alpha: 0 to 1
+//	tint: red, green and blue, from 0 to 255
+func (e *Engine) TweenActor(actor *Actor, property string, to []float64, duration float64, ease Ease, yoyo bool) (*Tween, error) {
+	from, err := actor.tweenValues(property)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single scale is used for both directions.
+	if property == "scale" && len(to) == 1 {
+		to = []float64{to[0], to[0]}
+	}
+	if len(to) != len(from) {
+		return nil, errors.New("tweenactor: wrong number of values for " + property)
+	}
+
+	// Replace anything already changing this property.
+	e.StopTween(actor, property)
+
+	tween := &Tween{Actor: actor, Property: property, From: from, To: to, Duration: duration, Ease: ease, Yoyo: yoyo}
+	e.Tweens = append(e.Tweens, tween)
+
+	return tween, nil
+}
+
+// StopTween will stop the tween running on the actor property, leaving the
+// actor as it currently is.
+func (e *Engine) StopTween(actor *Actor, property string) {
+	newTweens := make([]*Tween, 0)
+	for _, t := range e.Tweens {
+		if t.Actor != actor || t.Property != property {
+			newTweens = append(newTweens, t)
+		}
+	}
+	e.Tweens = newTweens
+}
+
+// ProcessTweens will move along all of the running tweens, removing those
+// that have finished.
+func (e *Engine) ProcessTweens() {
+	running := make([]*Tween, 0)
+	finished := make([]*Tween, 0)
+	for _, t := range e.Tweens {
+		if t.Update(e.Dt) {
+			finished = append(finished, t)
+		} else {
+			running = append(running, t)
+		}
+	}
+	e.Tweens = running
+
+	// Listeners may well start new tweens, so let them know last.
+	for _, t := range finished {
+		e.Emit(&Event{Name: "tween", Actor: t.Actor, Data: map[string]interface{}{"property": t.Property}})
+	}
+}
+
+// tweenValues will return the current values of the property.
+func (a *Actor) tweenValues(property string) ([]float64, error) {
+	switch property {
+	case "scale":
+		scale := a.Scale()
+		return []float64{scale.X, scale.Y}, nil
+	case "rotation":
+		return []float64{a.Rotation}, nil
+	case "alpha":
+		return []float64{a.Alpha()}, nil
+	case "tint":
+		tint := a.Tint
+		if tint == (color.RGBA{}) {
+			tint = color.RGBA{255, 255, 255, 255}
+		}
+		return []float64{float64(tint.R), float64(tint.G), float64(tint.B)}, nil
+	}
+	return nil, errors.New("tweenactor: unknown property " + property)
+}
+
+// setTweenValues will apply the values to the property.
+func (a *Actor) setTweenValues(property string, values []float64) {
+	switch property {
+	case "scale":
+		a.SetScale(pixel.V(values[0], values[1]))
+	case "rotation":
+		a.SetRotation(values[0])
+	case "alpha":
+		a.SetAlpha(values[0])
+	case "tint":
+		a.Tint = color.RGBA{uint8(math.Round(values[0])), uint8(math.Round(values[1])), uint8(math.Round(values[2])), 255}
+	}
+}
@@ -2,6 +2,9 @@ package gamesys
 
 import (
 	"image"
+	_ "image/gif"  // LoadImage reads gif images.
+	_ "image/jpeg" // LoadImage reads jpeg images.
+	_ "image/png"  // LoadImage reads png images.
 	"math"
 	"os"
 	"strconv"
@@ -55,6 +58,17 @@ func Contains(container pixel.Rect, target pixel.Rect) bool {
 	return true
 }
 
+// SnapDirection will round a direction in degrees to the nearest of the
+// given number of evenly spaced directions, keeping it between 0 and 359.
+func SnapDirection(direction int, directions int) int {
+	step := 360.0 / float64(directions)
+	snapped := int(math.Round(float64(direction)/step)*step) % 360
+	if snapped < 0 {
+		snapped += 360
+	}
+	return snapped
+}
+
 // Some generic stuff we could break out later.
 
 // StrFloat will return a string as a float64
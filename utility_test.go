@@ -38,6 +38,13 @@ func TestContains(t *testing.T) {
 	assert.False(t, Contains(target, edge), "Target on edge is should be false")
 }
 
+func TestSnapDirection(t *testing.T) {
+	assert.Equal(t, 45, SnapDirection(50, 8), "50 should snap to 45 with 8 directions")
+	assert.Equal(t, 90, SnapDirection(50, 4), "50 should snap to 90 with 4 directions")
+	assert.Equal(t, 0, SnapDirection(350, 4), "350 should wrap around to 0")
+	assert.Equal(t, 270, SnapDirection(-80, 4), "Negative directions should wrap around")
+}
+
 func TestStrFloat(t *testing.T) {
 	// Expected input
 	f := StrFloat("5.0")
@@ -3,6 +3,7 @@ package gamesys
 import (
 	"errors"
 	"image/color"
+	"sort"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
@@ -19,9 +20,20 @@ type View struct {
 	// Background is the background color of the view.
 	Background color.RGBA
 
-	// Src indicates the source picture data to draw from
+	// Map is the map the view shows, if it is a map view.
+	Map *Map
+
+	// Src is the first layer picture of a pre-rendered map.
 	Src *pixel.PictureData
 
+	// Output shows each layer of a pre-rendered map, being the part of the
+	// layer picture the camera sees. Maps that aren't pre-rendered have
+	// their tiles drawn instead.
+	Output []*pixel.Sprite
+
+	// outputVersions are the versions of the layer pictures shown.
+	outputVersions []int
+
 	// Focus is the actor that the view will follow. This actor will be
 	// restricted to the bounds of the view.
 	Focus *Actor
@@ -30,8 +42,9 @@ type View struct {
 	// view.
 	VisibleActors []string
 
-	// Output is the rendered map which is drawn to the screen.
-	Output []*pixel.Sprite
+	// YSort will draw actors lower on the view over those above them, within
+	// each actor layer. Turn it off to draw in VisibleActors order instead.
+	YSort bool
 
 	// Rendered is our background canvas to draw onto which will be
 	// flipped to the screen.
@@ -70,8 +83,26 @@ func (v *View) UseMap() error {
 	// We should only be processing map data on a map
 	// view, so this code needs a better home.
 	if v.Scene.MapData != nil {
-		v.Src = v.Scene.MapData.Img[0]
-		v.Output = append(v.Output, pixel.NewSprite(v.Src, v.Src.Bounds()))
+		if len(v.Scene.MapData.Layers) == 0 {
+			return errors.New("usemap: the map has no tile layers")
+		}
+
+		// Our tiles are drawn as they come into view.
+		err = v.Scene.MapData.Tiles.LoadTilesets()
+		if err != nil {
+			return err
+		}
+		v.Map = v.Scene.MapData
+
+		// Pre-rendered maps get one sprite for each layer, all the same size.
+		v.Src, v.Output, v.outputVersions = nil, nil, nil
+		if len(v.Map.Img) > 0 {
+			v.Src = v.Map.Img[0]
+			for _, img := range v.Map.Img {
+				v.Output = append(v.Output, pixel.NewSprite(img, img.Bounds()))
+			}
+			v.outputVersions = make([]int, len(v.Output))
+		}
 		return nil
 	}
 
@@ -104,26 +135,35 @@ func (v *View) Render() {
 		// Clear the existing view
 		v.Rendered.Clear(v.Background)
 
-		// Output means we have a map view to process.
-		if v.Output != nil {
-			for _, o := range v.Output {
-				// Center on our actor if we have one.
-				if v.Focus != nil {
-					actor := v.Focus
-					actorPos := actor.Position.Sub(v.Camera.Min)
-					movement := actorPos.Sub(v.Rendered.Bounds().Center())
-					v.CenterOn(movement)
-				}
-
-				// Grab the relevent section of map and place onto our view.
-				o.Set(v.Src, v.Camera)
-				o.Draw(v.Rendered, pixel.IM.Moved(v.Rendered.Bounds().Center()))
+		// Animated tiles follow the game clock.
+		if v.Map != nil {
+			v.Map.Tiles.Time = v.Engine.Time
+			if v.Output != nil {
+				v.Map.animateImg()
 			}
 		}
 
+		// A map means we have a map view to process.
+		if v.Map != nil {
+			// Center on our actor if we have one.
+			if v.Focus != nil {
+				actor := v.Focus
+				actorPos := actor.Position.Sub(v.Camera.Min)
+				movement := actorPos.Sub(v.Rendered.Bounds().Center())
+				v.CenterOn(movement)
+			}
+
+			v.drawMap(false)
+		}
+
 		// Now we work on the actors on the screen here.
-		for _, a := range v.VisibleActors {
-			v.Scene.Actors[a].Draw(v)
+		for _, a := range v.DrawOrder() {
+			a.Draw(v)
+		}
+
+		// Roofs and treetops go over our actors.
+		if v.Map != nil {
+			v.drawMap(true)
 		}
 
 		// See if this breaks first.
@@ -134,6 +174,58 @@ func (v *View) Render() {
 	}
 }
 
+// drawMap will draw the visible map layers that go either above or below
+// the actors, from the layer pictures when the map is pre-rendered.
+func (v *View) drawMap(above bool) {
+	if v.Output == nil {
+		v.Map.Tiles.Draw(v.Rendered, v.Camera, above)
+		return
+	}
+
+	for i, o := range v.Output {
+		layer := v.Map.Layers[i]
+		if layer.Above != above || !layer.Visible {
+			continue
+		}
+
+		// Changed pictures need a new sprite, as drawn pictures are kept.
+		if v.outputVersions[i] != v.Map.imgVersions[i] {
+			o = pixel.NewSprite(v.Map.Img[i], v.Map.Img[i].Bounds())
+			v.Output[i] = o
+			v.outputVersions[i] = v.Map.imgVersions[i]
+		}
+
+		// Grab the relevant section of map and place onto our view.
+		o.Set(v.Map.Img[i], v.Camera)
+		o.DrawColorMask(v.Rendered, pixel.IM.Moved(v.Rendered.Bounds().Center()), pixel.Alpha(layer.Opacity))
+	}
+}
+
+// DrawOrder will return the actors to draw on the view, in the order they
+// are drawn. Hidden actors and those outside the camera are left out. Lower
+// layers come first, and within a layer the highest actors come first so
+// those in front overlap those behind. Map positions are where things are
+// drawn whatever the map orientation, so this holds on isometric maps too.
+func (v *View) DrawOrder() []*Actor {
+	actors := make([]*Actor, 0, len(v.VisibleActors))
+	for _, id := range v.VisibleActors {
+		a := v.Scene.Actors[id]
+		if a == nil || !a.IsVisible() || !a.Clip.Intersects(v.Camera) {
+			continue
+		}
+		actors = append(actors, a)
+	}
+
+	sort.SliceStable(actors, func(i, j int) bool {
+		if actors[i].Layer != actors[j].Layer {
+			return actors[i].Layer < actors[j].Layer
+		}
+		return v.YSort && actors[i].Position.Y > actors[j].Position.Y
+	})
+
+	return actors
+}
+
 // FocusOn will focus on a specific actor
 func (v *View) FocusOn(actor *Actor) {
 	v.Focus = actor
@@ -141,22 +233,26 @@ func (v *View) FocusOn(actor *Actor) {
 
 // CenterOn will center the map on the actor who has the focus on that view.
 func (v *View) CenterOn(movement pixel.Vec) {
-	// Make a new temp camera based on where we would travel.
+	// Make a new temp camera based on where we would travel, kept on the map.
 	newCamera := v.Camera.Moved(movement)
+	bounds := pixel.R(0, 0, v.Map.Size.X, v.Map.Size.Y)
+	if v.Scene.CameraBounds != (pixel.Rect{}) {
+		bounds = v.Scene.CameraBounds
+	}
 
-	if newCamera.Min.X < v.Src.Rect.Min.X {
-		newCamera.Min.X = v.Src.Rect.Min.X
+	if newCamera.Min.X < bounds.Min.X {
+		newCamera.Min.X = bounds.Min.X
 		newCamera.Max.X = newCamera.Min.X + v.Camera.W()
-	} else if newCamera.Max.X > v.Src.Rect.Max.X {
-		newCamera.Max.X = v.Src.Rect.Max.X
+	} else if newCamera.Max.X > bounds.Max.X {
+		newCamera.Max.X = bounds.Max.X
 		newCamera.Min.X = newCamera.Max.X - v.Camera.W()
 	}
 
-	if newCamera.Min.Y < v.Src.Rect.Min.Y {
-		newCamera.Min.Y = v.Src.Rect.Min.Y
+	if newCamera.Min.Y < bounds.Min.Y {
+		newCamera.Min.Y = bounds.Min.Y
 		newCamera.Max.Y = newCamera.Min.Y + v.Camera.H()
-	} else if newCamera.Max.Y > v.Src.Rect.Max.Y {
-		newCamera.Max.Y = v.Src.Rect.Max.Y
+	} else if newCamera.Max.Y > bounds.Max.Y {
+		newCamera.Max.Y = bounds.Max.Y
 		newCamera.Min.Y = newCamera.Max.Y - v.Camera.H()
 	}
 
@@ -170,8 +266,12 @@ func (v *View) Draw() {
 		// Ensure our view is rendered and up to date.
 		v.Render()
 
-		// This should draw onto the scene.
-		v.Rendered.Draw(v.Scene.Rendered, pixel.IM.Moved(v.Position))
+		// This should draw onto the scene, with map views in the scene light.
+		var mask color.Color
+		if v.Map != nil {
+			mask = v.Scene.AmbientMask()
+		}
+		v.Rendered.DrawColorMask(v.Scene.Rendered, pixel.IM.Moved(v.Position), mask)
 	}
 }
 
@@ -181,6 +281,14 @@ func (v *View) CameraContains(target pixel.Rect) bool {
 	return Contains(v.Rendered.Bounds(), target)
 }
 
+// MapPosition will convert a window position, such as the mouse, into a
+// position on the map the view is showing. Handy for clicked movement.
+func (v *View) MapPosition(position pixel.Vec) pixel.Vec {
+	// Views are drawn centred on their position.
+	corner := v.Position.Sub(v.Rendered.Bounds().Center())
+	return v.Camera.Min.Add(position.Sub(corner))
+}
+
 // Move will move view position within the window.
 func (v *View) Move(position pixel.Vec) {
 	v.Position = position
@@ -0,0 +1,44 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewDrawOrder(t *testing.T) {
+	scene := &Scene{Engine: testEngine, Actors: make(map[string]*Actor)}
+	view := &View{Scene: scene, Camera: pixel.R(0, 0, 200, 200), YSort: true}
+
+	// Our actors, with the tree on a higher layer.
+	actor := func(id string, y float64, layer int, visible bool) *Actor {
+		a := &Actor{ID: id, Position: pixel.V(50, y), Layer: layer, Visible: visible}
+		a.Clip = pixel.R(40, y-10, 60, y+10)
+		scene.Actors[id] = a
+		view.VisibleActors = append(view.VisibleActors, id)
+		return a
+	}
+	tree := actor("tree", 150, 1, true)
+	hero := actor("hero", 20, 0, true)
+	npc := actor("npc", 100, 0, true)
+	actor("ghost", 60, 0, false)
+	actor("faraway", 500, 0, true)
+	view.VisibleActors = append(view.VisibleActors, "missing")
+
+	assert.Equal(t, []*Actor{npc, hero, tree}, view.DrawOrder(), "Layers first, then those lower on the view drawn last.")
+
+	view.YSort = false
+	assert.Equal(t, []*Actor{hero, npc, tree}, view.DrawOrder(), "Without sorting we keep our list order.")
+}
+
+func TestActorLayerAction(t *testing.T) {
+	hero := newTestActor("hero", pixel.ZV)
+	actionScene("layers", hero)
+	defer delete(testEngine.Scenes, "layers")
+
+	assert.NoError(t, runAction("ActorLayer", "layers", "hero", "3"))
+	assert.Equal(t, 3, hero.Layer, "Our layer should be set.")
+	assert.Error(t, runAction("ActorLayer", "layers", "nobody", "3"), "Unknown actors should be an error.")
+	assert.Error(t, runAction("ActorLayer", "nowhere", "hero", "3"), "Unknown scenes should be an error.")
+}
@@ -0,0 +1,319 @@
+package gamesys
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+
+	"github.com/faiface/pixel"
+	"github.com/lafriks/go-tiled"
+)
+
+// Warp is a door to somewhere else, such as another map. Warps come from
+// Tiled objects with the warp role, usually by giving them the "Warp" type,
+// and these properties:
+//
+//	scene       the scene to go to, created if it doesn't exist yet
+//	map         the map to load, also naming the scene when there's no scene
+//	spawn       the map object to arrive at, staying put when not set
+//	facing      the direction to face on arrival, in degrees or by name
+//	transition  the transition to play, being "fade"
+//	duration    how long the transition takes, in seconds
+//
+// Map properties given the Tiled file type are relative to the map file.
+type Warp struct {
+	// Name is the map object name.
+	Name string
+
+	// Area is where the warp is on the map.
+	Area pixel.Rect
+
+	// Scene is the id of the scene to go to.
+	Scene string
+
+	// Map is the map file to load into the scene.
+	Map string
+
+	// Spawn is the name of the map object to arrive at.
+	Spawn string
+
+	// Facing is the direction to face on arrival, when Turn is set.
+	Facing int
+	Turn   bool
+
+	// Transition is the transition played while warping, over the duration.
+	Transition string
+	Duration   float64
+}
+
+// NewWarp will read a warp from a Tiled map object, found on the map file.
+func NewWarp(obj *tiled.Object, area pixel.Rect, mapfile string) *Warp {
+	w := &Warp{
+		Name:       obj.Name,
+		Area:       area,
+		Scene:      obj.Properties.GetString("scene"),
+		Spawn:      obj.Properties.GetString("spawn"),
+		Transition: obj.Properties.GetString("transition"),
+		Duration:   StrFloat(obj.Properties.GetString("duration")),
+	}
+
+	for _, p := range obj.Properties {
+		switch p.Name {
+		case "map":
+			w.Map = p.Value
+			if p.Type == "file" {
+				w.Map = filepath.Join(filepath.Dir(mapfile), p.Value)
+			}
+		case "facing":
+			w.Facing, w.Turn = ParseFacing(p.Value), true
+		}
+	}
+
+	return w
+}
+
+// ParseFacing will read a direction in degrees, or by name being one of
+// right, up, left or down.
+func ParseFacing(value string) int {
+	for i, name := range []string{"right", "up", "left", "down"} {
+		if value == name {
+			return i * 90
+		}
+	}
+	facing, _ := strconv.Atoi(value)
+	return facing
+}
+
+// Destination will return the id of the scene the warp goes to.
+func (w *Warp) Destination() string {
+	if w.Scene != "" {
+		return w.Scene
+	}
+	return w.Map
+}
+
+// Warp will send the actor through the warp, along with anything attached
+// to it and any party members following it. The destination scene is
+// created when needed, copying our map views, and has the map loaded. The
+// destination becomes the active scene if we were, and its map views are
+// focused on the actor. A "warp" event is emitted with the scenes and the
+// warp in the data.
+//
+// Warps with a transition play it, warping halfway through. The destination
+// and spawn are checked before the transition starts, and anything going
+// wrong halfway is reported by a "warperror" event with the error and the
+// warp in the data.
+func (s *Scene) Warp(actor *Actor, w *Warp) error {
+	to, err := s.warpTarget(w)
+	if err != nil {
+		return err
+	}
+
+	if w.Transition != "" && w.Duration > 0 {
+		s.Engine.StartTransition(w.Transition, w.Duration, func() {
+			if err := s.warp(actor, w, to); err != nil {
+				s.Engine.Emit(&Event{Name: "warperror", Actor: actor, Data: map[string]interface{}{"error": err, "warp": w}})
+			}
+		})
+		return nil
+	}
+
+	return s.warp(actor, w, to)
+}
+
+// warpTarget will find the scene the warp goes to, making sure the spawn
+// point is there.
+func (s *Scene) warpTarget(w *Warp) (*Scene, error) {
+	id := w.Destination()
+	to := s
+	if id != "" {
+		to, err = s.warpScene(id, w.Map)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = to.warpSpawn(w, pixel.ZV)
+	if err != nil {
+		return nil, err
+	}
+
+	return to, nil
+}
+
+// warpSpawn will find where the warp arrives on the scene, staying at the
+// position without a spawn point.
+func (s *Scene) warpSpawn(w *Warp, position pixel.Vec) (pixel.Vec, error) {
+	if w.Spawn == "" {
+		return position, nil
+	}
+	if s.MapData == nil {
+		return position, errors.New("warp: scene " + s.sceneID() + " has no map to spawn on")
+	}
+	obj := s.MapData.FindObject(w.Spawn)
+	if obj == nil {
+		return position, errors.New("warp: spawn " + w.Spawn + " not found")
+	}
+	return s.MapData.ObjectRect(obj).Center(), nil
+}
+
+// warp will carry out the warp to the scene straight away.
+func (s *Scene) warp(actor *Actor, w *Warp, to *Scene) error {
+	e := s.Engine
+
+	// Find where we arrive, staying put without a spawn point.
+	position, err := to.warpSpawn(w, actor.Position)
+	if err != nil {
+		return err
+	}
+
+	// Everybody arrives together.
+	party := append([]*Actor{actor}, s.Followers(actor)...)
+	from := s.sceneID()
+	for _, a := range party {
+		if to != s {
+			err = e.TransferActor(a.ID, from, to.sceneID(), position, to.mapViews()...)
+			if err != nil {
+				return err
+			}
+		} else {
+			a.MoveTo(position)
+		}
+		a.Destinations = nil
+		if w.Turn {
+			a.Face(w.Facing)
+		}
+	}
+
+	// Our views follow us over.
+	for _, v := range to.mapViews() {
+		to.Views[v].FocusOn(actor)
+	}
+	if to != s && e.ActiveScene == s {
+		e.ActivateScene(to.sceneID())
+	}
+
+	// Don't warp straight back out if we land on a warp.
+	to.standingOn = to.warpAt(position)
+
+	e.Emit(&Event{Name: "warp", Actor: actor, Data: map[string]interface{}{"from": s, "to": to, "warp": w}})
+	return nil
+}
+
+// warpScene will find the scene to warp to, creating it with copies of our
+// map views when it doesn't exist. The map is loaded if the scene has none.
+func (s *Scene) warpScene(id string, mapfile string) (*Scene, error) {
+	e := s.Engine
+	to, ok := e.Scenes[id]
+	views := make([]string, 0)
+	if !ok {
+		if mapfile == "" {
+			return nil, errors.New("warp: scene " + id + " not found")
+		}
+
+		err := e.NewScene(id, "black")
+		if err != nil {
+			return nil, err
+		}
+		to = e.Scenes[id]
+		to.Background = s.Background
+		to.Movement = s.Movement
+
+		for _, v := range s.mapViews() {
+			view := s.Views[v]
+			to.NewView(v, view.Position, view.Camera, "black")
+			to.Views[v].Background = view.Background
+			to.Views[v].Visible = view.Visible
+			views = append(views, v)
+		}
+	}
+
+	if to.MapData == nil && mapfile != "" {
+		err := to.LoadMap(mapfile)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range views {
+			err = to.Views[v].UseMap()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return to, nil
+}
+
+// Followers will return the actors following the actor around the scene,
+// along with those following them in turn.
+func (s *Scene) Followers(actor *Actor) []*Actor {
+	followers := make([]*Actor, 0)
+	leaders := map[string]bool{actor.ID: true}
+
+	// Keep going until nobody new joins the party.
+	for found := true; found; {
+		found = false
+		for _, a := range filterActors(s.Actors, func(a *Actor) bool { return !leaders[a.ID] }) {
+			if f, ok := a.Behaviour.(*Follow); ok && leaders[f.Target] {
+				followers = append(followers, a)
+				leaders[a.ID] = true
+				found = true
+			}
+		}
+	}
+
+	return followers
+}
+
+// ProcessWarps will warp the focused actor when it steps onto a warp. The
+// warp it is standing on is remembered, so it has to step off and back on
+// to use it again. Warps that fail emit a "warperror" event.
+func (s *Scene) ProcessWarps() {
+	actor := s.FocusedActor()
+	if actor == nil || s.MapData == nil || s.Engine.Transition != nil {
+		return
+	}
+
+	w := s.warpAt(actor.Position)
+	entered := w != nil && w != s.standingOn
+	s.standingOn = w
+	if entered {
+		if err := s.Warp(actor, w); err != nil {
+			s.Engine.Emit(&Event{Name: "warperror", Actor: actor, Data: map[string]interface{}{"error": err, "warp": w}})
+		}
+	}
+}
+
+// warpAt will return the warp covering the position, if any.
+func (s *Scene) warpAt(position pixel.Vec) *Warp {
+	if s.MapData == nil {
+		return nil
+	}
+	for _, w := range s.MapData.Warps {
+		if w.Area.Contains(position) {
+			return w
+		}
+	}
+	return nil
+}
+
+// mapViews will return the ids of the views showing the map, in order.
+func (s *Scene) mapViews() []string {
+	views := make([]string, 0)
+	for _, id := range s.ViewOrder {
+		if v, ok := s.Views[id]; ok && v.Map != nil {
+			views = append(views, id)
+		}
+	}
+	return views
+}
+
+// sceneID will return the id the scene was added to the engine with.
+func (s *Scene) sceneID() string {
+	for id, scene := range s.Engine.Scenes {
+		if scene == s {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package gamesys
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+	"github.com/stretchr/testify/assert"
+)
+
+// warpTown will set up a scene on the warps map, with a hero and a pet
+// following them about.
+func warpTown(t *testing.T) (*Scene, *Actor, *Actor) {
+	e := testEngine
+	assert.NoError(t, e.NewScene("warptown", "black"))
+	scene := e.Scenes["warptown"]
+	assert.NoError(t, scene.LoadMap("test_assets/maps/warps.tmx"))
+	scene.NewView("main", pixel.V(320, 240), pixel.R(0, 0, 128, 128), "black")
+	assert.NoError(t, scene.Views["main"].UseMap())
+
+	hero := newTestActor("hero", pixel.V(128, 128))
+	pet := newTestActor("pet", pixel.V(120, 128))
+	pet.Behaviour = &Follow{Target: "hero", Distance: 8}
+	for _, a := range []*Actor{hero, pet} {
+		e.AddActor(a.ID, a)
+		assert.NoError(t, scene.UseActor(a.ID))
+	}
+	scene.Views["main"].FocusOn(hero)
+
+	return scene, hero, pet
+}
+
+func TestMapWarps(t *testing.T) {
+	m, err := NewMap("test_assets/maps/warps.tmx")
+	assert.NoError(t, err)
+	assert.Len(t, m.Warps, 2)
+
+	door := m.Warps[0]
+	assert.Equal(t, "Door", door.Name)
+	assert.Equal(t, pixel.R(0, 224, 32, 256), door.Area)
+	assert.Equal(t, "test_assets/maps/warproom.tmx", door.Map, "File properties are relative to the map.")
+	assert.Equal(t, "test_assets/maps/warproom.tmx", door.Destination())
+	assert.Equal(t, "Entrance", door.Spawn)
+	assert.True(t, door.Turn)
+	assert.Equal(t, 90, door.Facing)
+
+	assert.Equal(t, 180, ParseFacing("left"))
+	assert.Equal(t, 45, ParseFacing("45"))
+}
+
+func TestSceneWarp(t *testing.T) {
+	e := testEngine
+	scene, hero, pet := warpTown(t)
+	defer func() {
+		delete(e.Scenes, "warptown")
+		delete(e.Scenes, "test_assets/maps/warproom.tmx")
+		e.ActivateScene("test1")
+	}()
+	e.ActivateScene("warptown")
+
+	// The stairs take us elsewhere on the same map.
+	var warped *Event
+	e.Listen("warp", "warptest", func(ev *Event) { warped = ev })
+	defer e.Unlisten("warp", "warptest")
+	hero.MoveTo(scene.MapData.Warps[1].Area.Center())
+	scene.ProcessWarps()
+	assert.Equal(t, pixel.V(48, 80), hero.Position, "We should arrive at the landing.")
+	assert.Equal(t, pixel.V(48, 80), pet.Position, "The pet comes along.")
+	assert.Equal(t, 180, hero.Facing)
+	if assert.NotNil(t, warped) {
+		assert.Equal(t, hero, warped.Actor)
+		assert.Equal(t, scene, warped.Data["to"])
+	}
+
+	// The door takes us to a new scene built from the room map.
+	hero.MoveTo(pixel.V(16, 240))
+	scene.ProcessWarps()
+	room := e.Scenes["test_assets/maps/warproom.tmx"]
+	if !assert.NotNil(t, room, "The room scene should have been created.") {
+		return
+	}
+	assert.Equal(t, room, e.ActiveScene, "The room becomes the active scene.")
+	assert.Equal(t, hero, room.Actors["hero"])
+	assert.Equal(t, pet, room.Actors["pet"])
+	assert.Nil(t, scene.Actors["hero"], "We have left town.")
+	assert.Equal(t, pixel.V(48, 16), hero.Position)
+	assert.Equal(t, 90, hero.Facing)
+	if assert.Contains(t, room.Views, "main") {
+		assert.Equal(t, hero, room.Views["main"].Focus, "The view follows us.")
+		assert.Equal(t, room.MapData, room.Views["main"].Map)
+		assert.Contains(t, room.Views["main"].VisibleActors, "hero")
+	}
+
+	// We landed on the exit, but don't go back until we step off and on.
+	room.ProcessWarps()
+	assert.Equal(t, room, e.ActiveScene, "We shouldn't bounce straight back.")
+	hero.MoveTo(pixel.V(100, 100))
+	room.ProcessWarps()
+	hero.MoveTo(pixel.V(48, 16))
+	room.ProcessWarps()
+	assert.Equal(t, scene, e.ActiveScene, "Stepping back onto the exit takes us to town.")
+	assert.Equal(t, pixel.V(16, 240), hero.Position)
+}
+
+func TestWarpTransition(t *testing.T) {
+	e := testEngine
+	scene, hero, _ := warpTown(t)
+	defer delete(e.Scenes, "warptown")
+
+	w := &Warp{Spawn: "Landing", Transition: "fade", Duration: 1}
+	assert.NoError(t, scene.Warp(hero, w))
+	assert.NotNil(t, e.Transition)
+	assert.False(t, e.Transition.Update(0.25))
+	assert.Equal(t, pixel.V(128, 128), hero.Position, "We only warp halfway through.")
+	assert.Equal(t, pixel.RGB(0.5, 0.5, 0.5), e.Transition.Mask())
+
+	e.Transition.Update(0.25)
+	assert.Equal(t, pixel.V(48, 80), hero.Position, "The screen is dark, so we warp.")
+
+	e.Transition.Elapsed = 1
+	e.Dt = 0
+	e.ProcessTransition()
+	assert.Nil(t, e.Transition, "The transition is done.")
+	assert.Nil(t, e.Transition.Mask(), "No transition draws the scene as it is.")
+
+	// Bad destinations are caught before we fade out.
+	assert.Error(t, scene.Warp(hero, &Warp{Spawn: "Nowhere", Transition: "fade", Duration: 1}))
+	assert.Nil(t, e.Transition, "No transition should start for a bad spawn.")
+	assert.Error(t, scene.Warp(hero, &Warp{Scene: "nowhere", Transition: "fade", Duration: 1}))
+	assert.Nil(t, e.Transition, "No transition should start for a bad scene.")
+
+	// Anything going wrong halfway is announced.
+	var failed *Event
+	e.Listen("warperror", "warptest", func(ev *Event) { failed = ev })
+	defer e.Unlisten("warperror", "warptest")
+	assert.NoError(t, e.NewScene("warpaway", "black"))
+	assert.NoError(t, scene.Warp(hero, &Warp{Scene: "warpaway", Transition: "fade", Duration: 1}))
+	delete(e.Scenes, "warpaway")
+	e.Transition.Update(0.5)
+	e.Transition = nil
+	if assert.NotNil(t, failed, "A warp error should be emitted.") {
+		assert.Equal(t, hero, failed.Actor)
+		assert.Error(t, failed.Data["error"].(error))
+	}
+}
+
+func TestWarpScriptAction(t *testing.T) {
+	e := testEngine
+	scene, hero, _ := warpTown(t)
+	defer delete(e.Scenes, "warptown")
+
+	assert.Nil(t, e.RunScriptAction(&Action{Action: "Warp", Args: []interface{}{"warptown", "hero", "-", "Landing", "down"}}))
+	assert.Equal(t, pixel.V(48, 80), hero.Position)
+	assert.Equal(t, 270, hero.Facing)
+	assert.Equal(t, scene, e.Scenes["warptown"])
+
+	err := e.RunScriptAction(&Action{Action: "Warp", Args: []interface{}{"warptown", "hero", "-", "Nowhere"}})
+	assert.Error(t, err.(error), "Unknown spawn points are an error.")
+
+	// Bad arguments are errors rather than panics or dropped transitions.
+	assert.Error(t, runAction("Warp", "warptown", "nobody", "-", "Landing"), "Unknown actors should be an error.")
+	assert.Error(t, runAction("Warp", "nowhere", "hero", "-", "Landing"), "Unknown scenes should be an error.")
+	assert.Error(t, runAction("Warp", "warptown", "hero", "-", "Landing", "down", "fade"), "Transitions need a duration.")
+	assert.Nil(t, e.Transition, "No transition should start without one.")
+}
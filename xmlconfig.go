@@ -51,6 +51,10 @@ type Default struct {
 	MessageBox MessageBox `xml:"messagebox"`
 }
 
+// DefaultSkin is the scene skin width used when the configuration doesn't
+// give one.
+const DefaultSkin = 2.0
+
 // LoadConfiguration loads a configuration from the provided XML file.
 func LoadConfiguration(file string) (*Configuration, error) {
 	// New empty configuration
@@ -67,6 +71,9 @@ func LoadConfiguration(file string) (*Configuration, error) {
 	// read our opened xmlFile as a byte array.
 	byteValue, _ := ioutil.ReadAll(xmlFile)
 
+	// Settings missing from the file keep their defaults.
+	newconfig.Default.Scene.Skin = DefaultSkin
+
 	// Process XML file in the simplest way possible.
 	err = xml.Unmarshal(byteValue, &newconfig)
 
@@ -28,6 +28,8 @@ func TestLoadConfiguration(t *testing.T) {
 	// Are our speeds failing?
 	assert.Equal(t, 200.0, newconfig.Default.Scene.Basespeed, "Basespeed should not be 0")
 	assert.Equal(t, 1.0, newconfig.Default.Actor.Speed, "Actor speed modifier should not be 0")
+	assert.Equal(t, 2.0, newconfig.Default.Scene.Skin, "Scene skin width should be set")
+	assert.Equal(t, "Collision", newconfig.Default.Scene.SolidLayer, "Scene solid layer should be set")
 
 	// We need basic messagebox configuration
 	msgConfig := newconfig.Default.MessageBox
@@ -37,4 +39,9 @@ func TestLoadConfiguration(t *testing.T) {
 	assert.Equal(t, 240.0, msgConfig.Y, "We should have a Y position set")
 	assert.Equal(t, 100.0, msgConfig.Height, "We should have a height set")
 	assert.Equal(t, 200.0, msgConfig.Width, "We should have a width set")
+
+	// Missing settings keep their defaults.
+	newconfig, newerr = LoadConfiguration("test_assets/minimal.xml")
+	assert.NoError(t, newerr, "Error should not occur")
+	assert.Equal(t, DefaultSkin, newconfig.Default.Scene.Skin, "Scene skin width should default")
 }